@@ -0,0 +1,414 @@
+// Command go-config is a companion tool of the config package.
+//
+// Usage:
+//
+//	go-config keygen
+//	go-config encrypt [-key-file file | -key-env name] [-match regexp] file...
+//	go-config rotate [-key-file file | -key-env name] [-new-key-file file | -new-key-env name] file...
+//	go-config validate [-pkg package] -type name [-profile names] [-key-file file | -key-env name] file...
+//	go-config env [-pkg package] -type name [-prefix prefix]
+//	go-config explain [-pkg package] -type name [-prefix prefix] [-config file] [-profile names] key
+//	go-config schema [-pkg package] -type name
+//
+// The keygen command prints a new random key. The encrypt command encrypts values of yaml files in place,
+// which are not encrypted yet and whose dotted key path matches the regular expression. The rotate command
+// re-encrypts all encrypted values of yaml files in place using the new key.
+//
+// The remaining commands load the configuration struct type from the Go package (the current directory
+// by default) without compiling it, so code such as defaults and Validate methods is not run.
+// The validate command parses yaml files into the struct and reports unknown keys and invalid values.
+// The env command lists names of all environment variables read by the Env provider. The explain command
+// prints the values of the key set by the yaml file and the current environment and the resulting value.
+// The schema command prints JSON Schema of yaml files.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/tpodg/go-config"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "keygen":
+		err = keygen(stdout)
+	case "encrypt":
+		err = encrypt(args[1:], stderr)
+	case "rotate":
+		err = rotate(args[1:], stderr)
+	case "validate":
+		err = validate(args[1:], stdout, stderr)
+	case "env":
+		err = env(args[1:], stdout, stderr)
+	case "explain":
+		err = explain(args[1:], stdout, stderr)
+	case "schema":
+		err = schema(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		usage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "go-config %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage:
+  go-config keygen
+  go-config encrypt [-key-file file | -key-env name] [-match regexp] file...
+  go-config rotate [-key-file file | -key-env name] [-new-key-file file | -new-key-env name] file...
+  go-config validate [-pkg package] -type name [-profile names] [-key-file file | -key-env name] file...
+  go-config env [-pkg package] -type name [-prefix prefix]
+  go-config explain [-pkg package] -type name [-prefix prefix] [-config file] [-profile names] key
+  go-config schema [-pkg package] -type name
+`)
+}
+
+func keygen(stdout io.Writer) error {
+	key, err := config.GenerateKey()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, key)
+	return err
+}
+
+func encrypt(args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	keyFile := fs.String("key-file", "", "path of the file with base64 encoded key")
+	keyEnv := fs.String("key-env", "", "name of the environment variable with base64 encoded key")
+	match := fs.String("match", config.SecretPattern, "regular expression matching dotted key paths of values to encrypt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	re, err := regexp.Compile(*match)
+	if err != nil {
+		return err
+	}
+	key, err := config.LoadKey(*keyFile, *keyEnv)
+	if err != nil {
+		return err
+	}
+
+	return updateFiles(fs.Args(), func(b []byte) ([]byte, error) {
+		return config.EncryptYaml(b, key, func(path []string) bool {
+			return re.MatchString(strings.Join(path, "."))
+		})
+	})
+}
+
+func rotate(args []string, stderr io.Writer) error {
+	fs := flag.NewFlagSet("rotate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	keyFile := fs.String("key-file", "", "path of the file with current base64 encoded key")
+	keyEnv := fs.String("key-env", "", "name of the environment variable with current base64 encoded key")
+	newKeyFile := fs.String("new-key-file", "", "path of the file with new base64 encoded key")
+	newKeyEnv := fs.String("new-key-env", "", "name of the environment variable with new base64 encoded key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	oldKey, err := config.LoadKey(*keyFile, *keyEnv)
+	if err != nil {
+		return err
+	}
+	newKey, err := config.LoadKey(*newKeyFile, *newKeyEnv)
+	if err != nil {
+		return fmt.Errorf("new key: %w", err)
+	}
+
+	return updateFiles(fs.Args(), func(b []byte) ([]byte, error) {
+		return config.RotateYaml(b, oldKey, newKey)
+	})
+}
+
+// updateFiles transforms all files first and writes them only if all transformations succeed,
+// so a wrong key does not leave some of the files updated.
+func updateFiles(files []string, fn func(b []byte) ([]byte, error)) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no files")
+	}
+
+	updated := make([][]byte, len(files))
+	for i, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if updated[i], err = fn(b); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	for i, file := range files {
+		if err := writeFile(file, updated[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile replaces the file atomically, keeping its permissions.
+func writeFile(file string, b []byte) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, b, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// typeFlags select the configuration struct type.
+type typeFlags struct {
+	pkg  string
+	name string
+}
+
+func addTypeFlags(fs *flag.FlagSet) *typeFlags {
+	f := &typeFlags{}
+	fs.StringVar(&f.pkg, "pkg", ".", "Go package with the configuration type")
+	fs.StringVar(&f.name, "type", "", "name of the configuration struct type")
+	return f
+}
+
+func (f *typeFlags) load() (reflect.Type, error) {
+	if f.name == "" {
+		return nil, fmt.Errorf("no type")
+	}
+	return loadType(f.pkg, f.name)
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func validate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	typ := addTypeFlags(fs)
+	profiles := fs.String("profile", "", "comma-separated active profiles")
+	keyFile := fs.String("key-file", "", "path of the file with base64 encoded key")
+	keyEnv := fs.String("key-env", "", "name of the environment variable with base64 encoded key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no files")
+	}
+	t, err := typ.load()
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for _, file := range fs.Args() {
+		path, err := filepath.Abs(file)
+		if err == nil {
+			c := config.New()
+			c.WithProviders(&config.Yaml{
+				Path:     path,
+				Profiles: splitList(*profiles),
+				KeyFile:  *keyFile,
+				KeyEnv:   *keyEnv,
+				Strict:   true,
+			})
+			err = c.Parse(reflect.New(t).Interface())
+		}
+		if err != nil {
+			invalid++
+			fmt.Fprintf(stderr, "%s: %v\n", file, err)
+			continue
+		}
+		fmt.Fprintf(stdout, "%s: ok\n", file)
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d files invalid", invalid, fs.NArg())
+	}
+	return nil
+}
+
+func env(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("env", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	typ := addTypeFlags(fs)
+	prefix := fs.String("prefix", "", "prefix of environment variables")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	t, err := typ.load()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range config.EnvNames(*prefix, reflect.New(t).Interface()) {
+		if _, err := fmt.Fprintln(stdout, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// source is a provider with the name of values it sets.
+type source struct {
+	name     func(path string) string
+	provider config.Provider
+}
+
+func explain(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	typ := addTypeFlags(fs)
+	prefix := fs.String("prefix", "", "prefix of environment variables")
+	file := fs.String("config", "", "path of the yaml file, only environment variables are used if not set")
+	profiles := fs.String("profile", "", "comma-separated active profiles")
+	keyFile := fs.String("key-file", "", "path of the file with base64 encoded key")
+	keyEnv := fs.String("key-env", "", "name of the environment variable with base64 encoded key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("single key expected")
+	}
+	t, err := typ.load()
+	if err != nil {
+		return err
+	}
+
+	var sources []source
+	if *file != "" {
+		path, err := filepath.Abs(*file)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source{
+			name:     func(string) string { return "yaml " + *file },
+			provider: &config.Yaml{Path: path, Profiles: splitList(*profiles), KeyFile: *keyFile, KeyEnv: *keyEnv},
+		})
+	}
+	sources = append(sources, source{
+		name:     func(path string) string { return "env " + envName(*prefix, path) },
+		provider: &config.Env{Prefix: *prefix},
+	})
+
+	// values of the key and its nested values set by each source and the resulting values
+	key := fs.Arg(0)
+	var paths []string
+	lines := map[string][]string{}
+	collect := func(name func(path string) string, cfg interface{}) error {
+		changes, err := config.Diff(reflect.New(t).Interface(), cfg)
+		if err != nil {
+			return err
+		}
+		for _, ch := range changes {
+			if !matchKey(key, *prefix, ch.Path) {
+				continue
+			}
+			if _, ok := lines[ch.Path]; !ok {
+				paths = append(paths, ch.Path)
+			}
+			lines[ch.Path] = append(lines[ch.Path], fmt.Sprintf("  %s: %v", name(ch.Path), ch.New))
+		}
+		return nil
+	}
+
+	c := config.New()
+	for _, s := range sources {
+		cfg := reflect.New(t).Interface()
+		if err := s.provider.Provide(cfg); err != nil {
+			return err
+		}
+		if err := collect(s.name, cfg); err != nil {
+			return err
+		}
+		c.WithProviders(s.provider)
+	}
+	cfg := reflect.New(t).Interface()
+	if err := c.Parse(cfg); err != nil {
+		return err
+	}
+	if err := collect(func(string) string { return "value" }, cfg); err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		_, err := fmt.Fprintf(stdout, "%s is not set\n", key)
+		return err
+	}
+	for _, path := range paths {
+		fmt.Fprintln(stdout, path)
+		for _, line := range lines[path] {
+			fmt.Fprintln(stdout, line)
+		}
+	}
+	return nil
+}
+
+// envName returns the name of the environment variable of the dotted configuration path.
+func envName(prefix, path string) string {
+	name := strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+	if prefix == "" {
+		return name
+	}
+	return strings.ToUpper(strings.TrimSuffix(prefix, "_")) + "_" + name
+}
+
+// matchKey reports whether the dotted configuration path is the key, or nested in it. The key is either
+// a dotted path or a name of environment variable, both case-insensitive.
+func matchKey(key, prefix, path string) bool {
+	name, keyName := envName("", path), envName("", key)
+	if prefix != "" {
+		keyName = strings.TrimPrefix(keyName, envName("", strings.TrimSuffix(prefix, "_"))+"_")
+	}
+	return name == keyName || strings.HasPrefix(name, keyName+"_")
+}
+
+func schema(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	typ := addTypeFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	t, err := typ.load()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonSchema(t, typ.name))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tpodg/go-config"
+)
+
+func TestEncryptRotate(t *testing.T) {
+	dir := t.TempDir()
+	keyFile, newKeyFile := filepath.Join(dir, "key"), filepath.Join(dir, "new.key")
+	for _, file := range []string{keyFile, newKeyFile} {
+		var stdout bytes.Buffer
+		if code := run([]string{"keygen"}, &stdout, os.Stderr); code != 0 {
+			t.Fatalf("Exit code is %d, but 0 expected", code)
+		}
+		if err := os.WriteFile(file, stdout.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("host: localhost\ndb_password: s3cr3t\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	if code := run([]string{"encrypt", "-key-file", keyFile, file}, os.Stdout, &stderr); code != 0 {
+		t.Fatalf("Exit code is %d, but 0 expected: %s", code, stderr.String())
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "host: localhost") || strings.Contains(string(b), "s3cr3t") {
+		t.Errorf("Value is '%s', but only password encrypted expected", b)
+	}
+
+	if code := run([]string{"rotate", "-key-file", keyFile, "-new-key-file", newKeyFile, file}, os.Stdout, &stderr); code != 0 {
+		t.Fatalf("Exit code is %d, but 0 expected: %s", code, stderr.String())
+	}
+
+	var cfg struct {
+		Host       string
+		DBPassword string `yaml:"db_password"`
+	}
+	if err := (&config.Yaml{Path: file, KeyFile: keyFile}).Provide(&cfg); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	if err := (&config.Yaml{Path: file, KeyFile: newKeyFile}).Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.DBPassword != "s3cr3t" {
+		t.Errorf("Value is '%s', but %q expected", cfg.DBPassword, "s3cr3t")
+	}
+
+	// wrong key leaves the file unchanged
+	before, _ := os.ReadFile(file)
+	if code := run([]string{"rotate", "-key-file", keyFile, "-new-key-file", newKeyFile, file}, os.Stdout, &stderr); code != 1 {
+		t.Fatalf("Exit code is %d, but 1 expected", code)
+	}
+	after, _ := os.ReadFile(file)
+	if !bytes.Equal(before, after) {
+		t.Errorf("File was modified by failed rotation")
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := run([]string{"unknown"}, os.Stdout, &stderr); code != 2 {
+		t.Fatalf("Exit code is %d, but 2 expected", code)
+	}
+}
+
+const testPkg = "./testdata/app"
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	single := filepath.Join(dir, "single.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	files := map[string]string{
+		valid: "name: app\nservers:\n  - host: a\n    timeout: 5s\ndb:\n  url: postgres://db\nlabels:\n  team: core\n" +
+			"---\nprofile: prod\nweights: [1, 2]\n",
+		single:  "profile: prod\nname: app\n",
+		invalid: "name: app\nservers:\n  - hots: a\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"validate", "-pkg", testPkg, "-type", "Config", "-profile", "prod", valid, single}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("Exit code is %d, but 0 expected: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), valid+": ok") || !strings.Contains(stdout.String(), single+": ok") {
+		t.Errorf("Value is '%s', but %q expected", stdout.String(), valid+": ok")
+	}
+
+	stderr.Reset()
+	args = []string{"validate", "-pkg", testPkg, "-type", "Config", valid, invalid}
+	if code := run(args, &stdout, &stderr); code != 1 {
+		t.Fatalf("Exit code is %d, but 1 expected", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown field "servers.0.hots"`) || !strings.Contains(stderr.String(), "1 of 2 files invalid") {
+		t.Errorf("Value is '%s', but unknown field expected", stderr.String())
+	}
+}
+
+func TestEnv(t *testing.T) {
+	var stdout bytes.Buffer
+	if code := run([]string{"env", "-pkg", testPkg, "-type", "Config", "-prefix", "app"}, &stdout, os.Stderr); code != 0 {
+		t.Fatalf("Exit code is %d, but 0 expected", code)
+	}
+	expected := `APP_NAME
+APP_SERVERS_<N>_HOST
+APP_SERVERS_<N>_PORT
+APP_SERVERS_<N>_TIMEOUT
+APP_DB_URL
+APP_DB_PASSWORD
+APP_LABELS_<KEY>
+APP_WEIGHTS_<N>
+APP_EXTRA
+`
+	if stdout.String() != expected {
+		t.Errorf("Value is '%s', but '%s' expected", stdout.String(), expected)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(file, []byte("db:\n  url: postgres://yaml\n  password: s3cr3t\nname: app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_DB_URL", "postgres://env")
+
+	var stdout bytes.Buffer
+	args := []string{"explain", "-pkg", testPkg, "-type", "Config", "-prefix", "APP", "-config", file, "db"}
+	if code := run(args, &stdout, os.Stderr); code != 0 {
+		t.Fatalf("Exit code is %d, but 0 expected", code)
+	}
+	expected := "db.url\n" +
+		"  yaml " + file + ": postgres://yaml\n" +
+		"  env APP_DB_URL: postgres://env\n" +
+		"  value: postgres://env\n" +
+		"db.password\n" +
+		"  yaml " + file + ": " + config.MaskedValue + "\n" +
+		"  value: " + config.MaskedValue + "\n"
+	if stdout.String() != expected {
+		t.Errorf("Value is '%s', but '%s' expected", stdout.String(), expected)
+	}
+
+	stdout.Reset()
+	args = []string{"explain", "-pkg", testPkg, "-type", "Config", "-prefix", "APP", "APP_LABELS"}
+	if code := run(args, &stdout, os.Stderr); code != 0 {
+		t.Fatalf("Exit code is %d, but 0 expected", code)
+	}
+	if stdout.String() != "APP_LABELS is not set\n" {
+		t.Errorf("Value is '%s', but %q expected", stdout.String(), "APP_LABELS is not set\n")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	var stdout bytes.Buffer
+	if code := run([]string{"schema", "-pkg", testPkg, "-type", "Config"}, &stdout, os.Stderr); code != 0 {
+		t.Fatalf("Exit code is %d, but 0 expected", code)
+	}
+
+	var schema struct {
+		Schema               string `json:"$schema"`
+		Type                 string
+		AdditionalProperties bool
+		Properties           map[string]struct {
+			Type       interface{}
+			Properties map[string]interface{}
+			Items      *struct {
+				Properties map[string]struct {
+					Type interface{}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &schema); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if schema.Schema != schemaVersion || schema.Type != "object" || schema.AdditionalProperties {
+		t.Errorf("Value is '%+v', but closed object schema expected", schema)
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expected := []string{"db", "extra", "labels", "name", "servers", "started", "weights"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Value is '%v', but %v expected", names, expected)
+	}
+	timeout := schema.Properties["servers"].Items.Properties["timeout"].Type
+	if !reflect.DeepEqual(timeout, []interface{}{"string", "integer"}) {
+		t.Errorf("Value is '%v', but duration type expected", timeout)
+	}
+	if _, ok := schema.Properties["db"].Properties["url"]; !ok {
+		t.Errorf("Value is '%v', but url property expected", schema.Properties["db"].Properties)
+	}
+}
+
+func TestLoadTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  string
+	}{
+		{"Missing", "type Missing not found"},
+		{"Node", "recursive type Node is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			if code := run([]string{"env", "-pkg", testPkg, "-type", tt.name}, os.Stdout, &stderr); code != 1 {
+				t.Fatalf("Exit code is %d, but 1 expected", code)
+			}
+			if !strings.Contains(stderr.String(), tt.err) {
+				t.Errorf("Value is '%s', but %q expected", stderr.String(), tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+)
+
+const schemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema returns JSON Schema of yaml documents decoded into the configuration type. Property names
+// are the keys read by the Yaml provider and unknown properties are not allowed, the same as in strict mode.
+func jsonSchema(t reflect.Type, title string) map[string]interface{} {
+	schema := typeSchema(t)
+	schema["$schema"] = schemaVersion
+	schema["title"] = title
+	return schema
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == durationType:
+		return map[string]interface{}{"type": []string{"string", "integer"}}
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem()), "maxItems": t.Len()}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		structProperties(t, properties)
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	default:
+		// interfaces accept any value
+		return map[string]interface{}{}
+	}
+}
+
+// structProperties adds schemas of struct fields to properties, inline structs are flattened.
+func structProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		opts := strings.Split(tf.Tag.Get("yaml"), ",")
+		name := opts[0]
+		if name == "-" {
+			continue
+		}
+		ft := tf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && containsOption(opts[1:], "inline") {
+			structProperties(ft, properties)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(tf.Name)
+		}
+		properties[name] = typeSchema(tf.Type)
+	}
+}
+
+func containsOption(opts []string, opt string) bool {
+	for _, o := range opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
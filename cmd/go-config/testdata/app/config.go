@@ -0,0 +1,38 @@
+// Package app is a configuration loaded by tests of the go-config command.
+package app
+
+import "time"
+
+type Base struct {
+	Name string
+}
+
+type Server struct {
+	Host    string
+	Port    int
+	Timeout time.Duration
+}
+
+type Config struct {
+	Base
+	Servers  []Server  `yaml:"servers"`
+	Database *Database `yaml:"db"`
+	Labels   map[string]string
+	Weights  [2]uint
+	Started  time.Time
+	Extra    interface{}
+	internal string
+}
+
+type Database struct {
+	URL      string `yaml:"url"`
+	Password string `yaml:"password"`
+}
+
+type Node struct {
+	Children []Node
+}
+
+func (c *Config) Validate() error {
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	timeType      = reflect.TypeOf(time.Time{})
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+var basicTypes = map[types.BasicKind]reflect.Type{
+	types.Bool:       reflect.TypeOf(false),
+	types.Int:        reflect.TypeOf(int(0)),
+	types.Int8:       reflect.TypeOf(int8(0)),
+	types.Int16:      reflect.TypeOf(int16(0)),
+	types.Int32:      reflect.TypeOf(int32(0)),
+	types.Int64:      reflect.TypeOf(int64(0)),
+	types.Uint:       reflect.TypeOf(uint(0)),
+	types.Uint8:      reflect.TypeOf(uint8(0)),
+	types.Uint16:     reflect.TypeOf(uint16(0)),
+	types.Uint32:     reflect.TypeOf(uint32(0)),
+	types.Uint64:     reflect.TypeOf(uint64(0)),
+	types.Uintptr:    reflect.TypeOf(uintptr(0)),
+	types.Float32:    reflect.TypeOf(float32(0)),
+	types.Float64:    reflect.TypeOf(float64(0)),
+	types.Complex64:  reflect.TypeOf(complex64(0)),
+	types.Complex128: reflect.TypeOf(complex128(0)),
+	types.String:     reflect.TypeOf(""),
+}
+
+// loadType type-checks the Go package from source and converts the struct type to an equivalent reflect
+// type, so configuration can be parsed without compiling the package. The package is an import path or
+// a directory relative to the working directory. Named types are replaced by their underlying types,
+// except time.Duration and time.Time, so custom unmarshalers and methods are not used.
+func loadType(pkgPath, name string) (reflect.Type, error) {
+	// dependencies are type-checked from source as well, export data of compiled packages is not read,
+	// so the tool works with toolchains newer than the x/tools module
+	mode := packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps
+	pkgs, err := packages.Load(&packages.Config{Mode: mode}, pkgPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("package %s not found", pkgPath)
+	}
+	var loadErr error
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if loadErr == nil && len(p.Errors) > 0 {
+			loadErr = p.Errors[0]
+		}
+	})
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	pkg := pkgs[0].Types
+
+	obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in package %s", name, pkg.Path())
+	}
+	if _, ok := obj.Type().Underlying().(*types.Struct); !ok {
+		return nil, fmt.Errorf("type %s is not a struct", name)
+	}
+	return (&typeConverter{visiting: map[*types.Named]bool{}}).convert(obj.Type())
+}
+
+type typeConverter struct {
+	visiting map[*types.Named]bool
+}
+
+func (c *typeConverter) convert(t types.Type) (reflect.Type, error) {
+	switch t := t.(type) {
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "time" {
+			switch obj.Name() {
+			case "Duration":
+				return durationType, nil
+			case "Time":
+				return timeType, nil
+			}
+		}
+		// reflect can not create recursive types
+		if c.visiting[t] {
+			return nil, fmt.Errorf("recursive type %s is not supported", obj.Name())
+		}
+		c.visiting[t] = true
+		defer delete(c.visiting, t)
+		return c.convert(t.Underlying())
+	case *types.Basic:
+		if rt, ok := basicTypes[t.Kind()]; ok {
+			return rt, nil
+		}
+	case *types.Pointer:
+		elem, err := c.convert(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return reflect.PointerTo(elem), nil
+	case *types.Slice:
+		elem, err := c.convert(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elem), nil
+	case *types.Array:
+		elem, err := c.convert(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return reflect.ArrayOf(int(t.Len()), elem), nil
+	case *types.Map:
+		key, err := c.convert(t.Key())
+		if err != nil {
+			return nil, err
+		}
+		elem, err := c.convert(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(key, elem), nil
+	case *types.Interface:
+		return interfaceType, nil
+	case *types.Struct:
+		return c.convertStruct(t)
+	default:
+		// aliases and type parameters
+		if u := t.Underlying(); u != t {
+			return c.convert(u)
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// convertStruct converts exported and embedded fields of the struct. Embedded fields are converted
+// to named fields, embedded structs without a yaml name are inlined, so they are flattened the same way.
+func (c *typeConverter) convertStruct(t *types.Struct) (reflect.Type, error) {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumFields(); i++ {
+		f := t.Field(i)
+		if !f.Exported() && !f.Embedded() {
+			continue
+		}
+		ft, err := c.convert(f.Type())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+
+		name, tag := f.Name(), reflect.StructTag(t.Tag(i))
+		if f.Embedded() {
+			r, size := utf8.DecodeRuneInString(name)
+			name = string(unicode.ToUpper(r)) + name[size:]
+			st := ft
+			if st.Kind() == reflect.Ptr {
+				st = st.Elem()
+			}
+			if st.Kind() == reflect.Struct && strings.Split(tag.Get("yaml"), ",")[0] == "" {
+				tag = inlineTag(tag)
+			}
+		}
+		fields = append(fields, reflect.StructField{Name: name, Type: ft, Tag: tag})
+	}
+	return reflect.StructOf(fields), nil
+}
+
+// inlineTag adds the inline option to the yaml tag.
+func inlineTag(tag reflect.StructTag) reflect.StructTag {
+	if v, ok := tag.Lookup("yaml"); ok {
+		if v == "-" {
+			return tag
+		}
+		return reflect.StructTag(strings.Replace(string(tag), `yaml:"`+v+`"`, `yaml:"`+v+`,inline"`, 1))
+	}
+	return reflect.StructTag(strings.TrimSpace(string(tag) + ` yaml:",inline"`))
+}
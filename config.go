@@ -4,8 +4,12 @@
 package config
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io/fs"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -17,6 +21,59 @@ type Provider interface {
 	Provide(config interface{}) error
 }
 
+// ContextProvider is an optional interface of providers, which read configuration from remote or slow
+// sources. Such providers should honor cancellation and deadline of the context.
+type ContextProvider interface {
+	Provider
+
+	// ProvideContext reads values like Provide, but stops and returns an error when ctx is done.
+	ProvideContext(ctx context.Context, config interface{}) error
+}
+
+// Watcher is an optional interface of providers, which are able to notify about changes of the configuration source.
+type Watcher interface {
+
+	// Watch returns a channel, which receives a value whenever the configuration source changes.
+	// The channel is closed when ctx is done.
+	Watch(ctx context.Context) (<-chan struct{}, error)
+}
+
+// UnsetProvider is an optional interface of providers, which are able to explicitly clear values
+// configured by providers with lower priority, e.g. delete map entries or nil out pointers.
+type UnsetProvider interface {
+	Provider
+
+	// ProvideUnset reads values like Provide and additionally returns paths of values which are
+	// explicitly unset in the configuration source. Path segments are configuration field names,
+	// slice indices and map keys.
+	ProvideUnset(config interface{}) ([][]string, error)
+}
+
+// ContextUnsetProvider is an optional interface of providers, which honor the context and are able
+// to explicitly clear values, see ContextProvider and UnsetProvider.
+type ContextUnsetProvider interface {
+	Provider
+
+	// ProvideUnsetContext reads values like ProvideUnset, but stops and returns an error when ctx is done.
+	ProvideUnsetContext(ctx context.Context, config interface{}) ([][]string, error)
+}
+
+// Optional wraps the provider, so a missing configuration source is skipped instead of failing the parsing.
+// A source is missing if the provider returns an error matching fs.ErrNotExist, e.g. file or directory
+// does not exist. Other errors, e.g. invalid content of an existing file, are still returned.
+func Optional(p Provider) Provider {
+	return &optionalProvider{Provider: p}
+}
+
+type optionalProvider struct {
+	Provider
+}
+
+// Unwrap returns the wrapped provider
+func (o *optionalProvider) Unwrap() Provider {
+	return o.Provider
+}
+
 // C is a wrapper struct holding a slice of configuration sources, which must implement Provider interface.
 type C struct {
 	providers []Provider
@@ -29,11 +86,12 @@ func New() *C {
 	}
 }
 
-// Default is a constructor method which initializes default providers.
+// Default is a constructor method which initializes default providers. The yaml file is optional,
+// so configuration can be provided by environment variables only.
 func Default() *C {
 	return &C{
 		providers: []Provider{
-			&Yaml{},
+			Optional(&Yaml{}),
 			&Env{},
 		},
 	}
@@ -46,6 +104,12 @@ func (c *C) WithProviders(providers ...Provider) {
 
 // Parse loops through providers and parses configuration.
 func (c *C) Parse(config interface{}) error {
+	return c.ParseContext(context.Background(), config)
+}
+
+// ParseContext loops through providers and parses configuration. Parsing is stopped when ctx is done,
+// providers implementing ContextProvider interface receive ctx to be able to cancel reading of values.
+func (c *C) ParseContext(ctx context.Context, config interface{}) error {
 	cfgVal := reflect.ValueOf(config)
 
 	err := validateConfig(cfgVal)
@@ -53,13 +117,43 @@ func (c *C) Parse(config interface{}) error {
 		return err
 	}
 
+	err = validateMergeTags(cfgVal.Type().Elem(), map[reflect.Type]bool{})
+	if err != nil {
+		return err
+	}
+
 	for _, p := range c.providers {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		optional := false
+		if o, ok := p.(*optionalProvider); ok {
+			p, optional = o.Provider, true
+		}
+
 		source := reflect.New(reflect.TypeOf(config).Elem())
-		err := p.Provide(source.Interface())
+		var unset [][]string
+		switch pp := p.(type) {
+		case ContextUnsetProvider:
+			unset, err = pp.ProvideUnsetContext(ctx, source.Interface())
+		case ContextProvider:
+			err = pp.ProvideContext(ctx, source.Interface())
+		case UnsetProvider:
+			unset, err = pp.ProvideUnset(source.Interface())
+		default:
+			err = p.Provide(source.Interface())
+		}
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
 		if err != nil {
 			return err
 		}
 
+		for _, path := range unset {
+			unsetPath(cfgVal.Elem(), path)
+		}
 		mergeConfig(source, cfgVal)
 	}
 
@@ -164,6 +258,15 @@ func mergeSlice(source reflect.Value, target reflect.Value) {
 	}
 }
 
+func mergeArray(source reflect.Value, target reflect.Value) {
+	if !target.CanSet() {
+		return
+	}
+	for i := 0; i < source.Len(); i++ {
+		mergeSliceElement(source.Index(i), target.Index(i))
+	}
+}
+
 func mergeSliceElement(source reflect.Value, target reflect.Value) {
 	if source.Kind() == reflect.Ptr {
 		if source.IsNil() {
@@ -186,6 +289,10 @@ func mergeValue(source reflect.Value, target reflect.Value) {
 		mergeMap(source, target)
 	case reflect.Slice:
 		mergeSlice(source, target)
+	case reflect.Array:
+		mergeArray(source, target)
+	case reflect.Ptr:
+		mergePointer(source, target)
 	default:
 		if !source.IsZero() && target.CanSet() {
 			target.Set(source)
@@ -193,20 +300,48 @@ func mergeValue(source reflect.Value, target reflect.Value) {
 	}
 }
 
+// mergePointer deeply merges pointers to structs, allocating the target if needed.
+// Pointers to other types are replaced if the source is not nil.
+func mergePointer(source reflect.Value, target reflect.Value) {
+	if !target.CanSet() || source.IsNil() {
+		return
+	}
+	if source.Elem().Kind() != reflect.Struct {
+		target.Set(source)
+		return
+	}
+
+	// merge into a copy, so values shared with previous sources are not modified
+	merged := reflect.New(target.Type().Elem())
+	if !target.IsNil() {
+		merged.Elem().Set(target.Elem())
+	}
+	mergeStructValue(source.Elem(), merged.Elem())
+	target.Set(merged)
+}
+
 func mergeStructValue(source reflect.Value, target reflect.Value) {
 	for i := 0; i < target.NumField(); i++ {
 		f := target.Field(i)
+		s := source.Field(i)
 		if !f.CanSet() {
+			// Exported fields of an embedded struct with unexported type are still settable.
+			if target.Type().Field(i).Anonymous && f.Kind() == reflect.Struct {
+				mergeStructValue(s, f)
+			}
 			continue
 		}
-		s := source.Field(i)
 		switch f.Kind() {
 		case reflect.Struct:
 			mergeStructValue(s, f)
 		case reflect.Map:
 			mergeMap(s, f)
 		case reflect.Slice:
-			mergeSlice(s, f)
+			mergeSliceField(s, f, target.Type().Field(i))
+		case reflect.Array:
+			mergeArray(s, f)
+		case reflect.Ptr:
+			mergePointer(s, f)
 		default:
 			if !s.IsZero() {
 				f.Set(s)
@@ -215,6 +350,263 @@ func mergeStructValue(source reflect.Value, target reflect.Value) {
 	}
 }
 
+// Slice merge strategies, which can be configured per field using the `merge` struct tag.
+const (
+	// sliceMerge merges slices element by element by index, used by default
+	sliceMerge = "merge"
+	// sliceReplace replaces the whole slice with the one from the provider with higher priority
+	sliceReplace = "replace"
+	// sliceAppend appends elements from the provider with higher priority
+	sliceAppend = "append"
+	// sliceMergeByKey merges elements with equal value of the key field, e.g. `merge:"mergeByKey:name"`
+	sliceMergeByKey = "mergeByKey:"
+)
+
+func mergeSliceField(source reflect.Value, target reflect.Value, tField reflect.StructField) {
+	strategy := tField.Tag.Get("merge")
+	switch {
+	case strategy == sliceReplace:
+		replaceSlice(source, target)
+	case strategy == sliceAppend:
+		appendSlice(source, target)
+	case strings.HasPrefix(strategy, sliceMergeByKey):
+		keyIdx, _ := sliceKeyField(target.Type().Elem(), strings.TrimPrefix(strategy, sliceMergeByKey))
+		mergeSliceByKey(source, target, keyIdx)
+	default:
+		mergeSlice(source, target)
+	}
+}
+
+func replaceSlice(source reflect.Value, target reflect.Value) {
+	if !target.CanSet() || source.IsNil() || source.Len() == 0 {
+		return
+	}
+	s := reflect.MakeSlice(target.Type(), source.Len(), source.Len())
+	reflect.Copy(s, source)
+	target.Set(s)
+}
+
+func appendSlice(source reflect.Value, target reflect.Value) {
+	if !target.CanSet() || source.IsNil() || source.Len() == 0 {
+		return
+	}
+	s := reflect.MakeSlice(target.Type(), 0, target.Len()+source.Len())
+	s = reflect.AppendSlice(s, target)
+	s = reflect.AppendSlice(s, source)
+	target.Set(s)
+}
+
+func mergeSliceByKey(source reflect.Value, target reflect.Value, keyIdx []int) {
+	if !target.CanSet() || source.IsNil() || source.Len() == 0 {
+		return
+	}
+
+	s := reflect.MakeSlice(target.Type(), target.Len(), target.Len()+source.Len())
+	reflect.Copy(s, target)
+	for i := 0; i < source.Len(); i++ {
+		sVal := source.Index(i)
+		sKey, ok := sliceElementKey(sVal, keyIdx)
+		if !ok {
+			continue
+		}
+
+		pos := -1
+		for j := 0; j < s.Len(); j++ {
+			if tKey, ok := sliceElementKey(s.Index(j), keyIdx); ok && tKey.Interface() == sKey.Interface() {
+				pos = j
+				break
+			}
+		}
+		if pos == -1 {
+			s = reflect.Append(s, reflect.Zero(s.Type().Elem()))
+			pos = s.Len() - 1
+		}
+		mergeSliceElement(sVal, s.Index(pos))
+	}
+	target.Set(s)
+}
+
+func sliceElementKey(elem reflect.Value, keyIdx []int) (reflect.Value, bool) {
+	if elem.Kind() == reflect.Ptr {
+		if elem.IsNil() {
+			return reflect.Value{}, false
+		}
+		elem = elem.Elem()
+	}
+	return elem.FieldByIndex(keyIdx), true
+}
+
+// sliceKeyField finds the key field of slice elements by its configuration or struct field name.
+// The key field must be exported and comparable, interfaces are rejected as their values may not be.
+func sliceKeyField(elemType reflect.Type, key string) ([]int, bool) {
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for _, f := range reflect.VisibleFields(elemType) {
+		if f.Anonymous {
+			continue
+		}
+		name, ok := envFieldName(f)
+		if ok && (strings.EqualFold(name, key) || strings.EqualFold(f.Name, key)) {
+			return f.Index, f.IsExported() && isComparableKey(f.Type)
+		}
+	}
+	return nil, false
+}
+
+// isComparableKey reports whether all values of type t can be compared without panic.
+func isComparableKey(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return false
+	case reflect.Array:
+		return isComparableKey(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !isComparableKey(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	default:
+		return t.Comparable()
+	}
+}
+
+// validateMergeTags checks merge strategies of all slice fields reachable from t.
+func validateMergeTags(t reflect.Type, visited map[reflect.Type]bool) error {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return validateMergeTags(t.Elem(), visited)
+	case reflect.Struct:
+	default:
+		return nil
+	}
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		strategy, ok := f.Tag.Lookup("merge")
+		if ok {
+			if f.Type.Kind() != reflect.Slice {
+				return fmt.Errorf("merge strategy %q of field %s.%s can only be used with slices", strategy, t.Name(), f.Name)
+			}
+			switch {
+			case strategy == sliceMerge, strategy == sliceReplace, strategy == sliceAppend:
+			case strings.HasPrefix(strategy, sliceMergeByKey):
+				key := strings.TrimPrefix(strategy, sliceMergeByKey)
+				if _, ok := sliceKeyField(f.Type.Elem(), key); !ok {
+					return fmt.Errorf("invalid key %q in merge strategy of field %s.%s", key, t.Name(), f.Name)
+				}
+			default:
+				return fmt.Errorf("unknown merge strategy %q of field %s.%s", strategy, t.Name(), f.Name)
+			}
+		}
+		if err := validateMergeTags(f.Type, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unsetPath sets the value at path to its zero value, or deletes it if it is a map entry.
+func unsetPath(v reflect.Value, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	var next reflect.Value
+	switch v.Kind() {
+	case reflect.Struct:
+		f, ok := findConfigField(v, path[0])
+		if !ok {
+			return
+		}
+		next = f
+	case reflect.Slice, reflect.Array:
+		idx, err := strconv.Atoi(path[0])
+		if err != nil || idx < 0 || idx >= v.Len() {
+			return
+		}
+		next = v.Index(idx)
+	case reflect.Map:
+		key, ok := findMapKey(v, path[0])
+		if !ok {
+			return
+		}
+		if len(path) == 1 {
+			v.SetMapIndex(key, reflect.Value{})
+			return
+		}
+		// map elements are not addressable, modified copy is stored back
+		elem := reflect.New(v.Type().Elem()).Elem()
+		elem.Set(v.MapIndex(key))
+		unsetPath(elem, path[1:])
+		v.SetMapIndex(key, elem)
+		return
+	default:
+		return
+	}
+
+	if len(path) > 1 {
+		unsetPath(next, path[1:])
+		return
+	}
+	if next.CanSet() {
+		next.Set(reflect.Zero(next.Type()))
+	}
+}
+
+// findConfigField finds struct field by its configuration or struct field name, case-insensitively.
+// Fields of inline structs are searched as if they were declared in v, unexported fields are skipped.
+func findConfigField(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		fieldName, ok := envFieldName(tf)
+		if !ok || (!tf.IsExported() && !isInlineField(tf)) {
+			continue
+		}
+		if isInlineField(tf) && v.Field(i).Kind() == reflect.Struct {
+			if f, ok := findConfigField(v.Field(i), name); ok {
+				return f, true
+			}
+			continue
+		}
+		if strings.EqualFold(fieldName, name) || strings.EqualFold(tf.Name, name) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func findMapKey(m reflect.Value, key string) (reflect.Value, bool) {
+	if m.Type().Key().Kind() == reflect.String {
+		return findStringMapKey(m, key)
+	}
+	k := reflect.New(m.Type().Key()).Elem()
+	if isComplexType(k.Type()) || processField(k, key) != nil {
+		return reflect.Value{}, false
+	}
+	if !m.MapIndex(k).IsValid() {
+		return reflect.Value{}, false
+	}
+	return k, true
+}
+
 func findStringMapKey(m reflect.Value, key string) (reflect.Value, bool) {
 	keyVal := reflect.ValueOf(key)
 	if keyVal.Type().AssignableTo(m.Type().Key()) {
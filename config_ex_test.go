@@ -1,6 +1,11 @@
 package config_test
 
-import "github.com/tpodg/go-config"
+import (
+	"context"
+	"time"
+
+	"github.com/tpodg/go-config"
+)
 
 type pDummy struct{}
 
@@ -32,3 +37,18 @@ func ExampleC_WithProviders() {
 		// ...
 	}
 }
+
+func ExampleC_ParseContext() {
+	cfg := struct {
+		param string
+	}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := config.Default()
+	err := c.ParseContext(ctx, &cfg)
+	if err != nil {
+		// ...
+	}
+}
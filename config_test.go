@@ -1,6 +1,10 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -15,6 +19,9 @@ type pFull struct{}
 type pSimple struct{}
 type pMapBase struct{}
 type pMapOverride struct{}
+type pSlow struct {
+	called bool
+}
 
 func TestInitializeNewConfigWithCustomProvider(t *testing.T) {
 	c := New()
@@ -117,6 +124,44 @@ func TestSkipNonDefinedValue(t *testing.T) {
 	}
 }
 
+func TestParseContextDeadline(t *testing.T) {
+	slow := &pSlow{}
+	c := New()
+	c.WithProviders(slow, &pFull{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	conf := testCfg{}
+	err := c.ParseContext(ctx, &conf)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Error is '%v', but %v expected", err, context.DeadlineExceeded)
+	}
+	if !slow.called {
+		t.Errorf("Context provider should be called")
+	}
+	if conf.StringField != "" {
+		t.Errorf("Value is '%s', but providers after cancellation should not be applied", conf.StringField)
+	}
+}
+
+func TestParseContextCanceled(t *testing.T) {
+	slow := &pSlow{}
+	c := New()
+	c.WithProviders(slow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := c.ParseContext(ctx, &testCfg{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Error is '%v', but %v expected", err, context.Canceled)
+	}
+	if slow.called {
+		t.Errorf("Provider should not be called with canceled context")
+	}
+}
+
 func TestMergeMapConfig(t *testing.T) {
 	c := New()
 	c.WithProviders(&pMapBase{}, &pMapOverride{})
@@ -148,6 +193,334 @@ func TestMergeMapConfig(t *testing.T) {
 	}
 }
 
+func TestMergeEmbeddedStruct(t *testing.T) {
+	type base struct {
+		Level string
+		Port  int
+	}
+	type cfg struct {
+		base
+		Name string
+	}
+
+	source := cfg{base: base{Level: "debug"}}
+	target := cfg{base: base{Level: "info", Port: 8080}, Name: "api"}
+	mergeConfig(reflect.ValueOf(&source), reflect.ValueOf(&target))
+
+	if target.Level != "debug" {
+		t.Errorf("Value is '%s', but %q expected", target.Level, "debug")
+	}
+	if target.Port != 8080 {
+		t.Errorf("Value is '%d', but %d expected", target.Port, 8080)
+	}
+	if target.Name != "api" {
+		t.Errorf("Value is '%s', but %q expected", target.Name, "api")
+	}
+}
+
+func TestSliceMergeStrategies(t *testing.T) {
+	type upstream struct {
+		Name   string
+		Weight int
+	}
+	type cfg struct {
+		Merged   []string
+		Replaced []string    `merge:"replace"`
+		Appended []string    `merge:"append"`
+		Keyed    []*upstream `merge:"mergeByKey:name"`
+	}
+
+	c := New()
+	c.WithProviders(
+		&Map{Values: map[string]interface{}{
+			"merged":   []string{"a", "b", "c"},
+			"replaced": []string{"a", "b", "c"},
+			"appended": []string{"a", "b"},
+			"keyed": []map[string]interface{}{
+				{"name": "one", "weight": 1},
+				{"name": "two", "weight": 2},
+			},
+		}},
+		&Map{Values: map[string]interface{}{
+			"merged":   []string{"x"},
+			"replaced": []string{"x"},
+			"appended": []string{"x"},
+			"keyed": []map[string]interface{}{
+				{"name": "two", "weight": 20},
+				{"name": "three", "weight": 3},
+			},
+		}},
+	)
+
+	conf := cfg{}
+	if err := c.Parse(&conf); err != nil {
+		t.Fatalf("%v\n", err)
+	}
+
+	if !reflect.DeepEqual(conf.Merged, []string{"x", "b", "c"}) {
+		t.Errorf("Value is '%v', but %v expected", conf.Merged, []string{"x", "b", "c"})
+	}
+	if !reflect.DeepEqual(conf.Replaced, []string{"x"}) {
+		t.Errorf("Value is '%v', but %v expected", conf.Replaced, []string{"x"})
+	}
+	if !reflect.DeepEqual(conf.Appended, []string{"a", "b", "x"}) {
+		t.Errorf("Value is '%v', but %v expected", conf.Appended, []string{"a", "b", "x"})
+	}
+	expected := []*upstream{{"one", 1}, {"two", 20}, {"three", 3}}
+	if !reflect.DeepEqual(conf.Keyed, expected) {
+		t.Errorf("Value is '%v', but %v expected", conf.Keyed, expected)
+	}
+}
+
+func TestInvalidSliceMergeStrategy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config interface{}
+		errMsg string
+	}{
+		{
+			name: "Unknown strategy",
+			config: &struct {
+				Field []string `merge:"shuffle"`
+			}{},
+			errMsg: "unknown merge strategy",
+		},
+		{
+			name: "Unknown key",
+			config: &struct {
+				Field []struct{ Name string } `merge:"mergeByKey:id"`
+			}{},
+			errMsg: "invalid key",
+		},
+		{
+			name: "Unexported key",
+			config: &struct {
+				Field []struct{ name string } `merge:"mergeByKey:name"`
+			}{},
+			errMsg: "invalid key",
+		},
+		{
+			name: "Interface key",
+			config: &struct {
+				Field []struct{ Name interface{} } `merge:"mergeByKey:name"`
+			}{},
+			errMsg: "invalid key",
+		},
+		{
+			name: "Not a slice",
+			config: &struct {
+				Field string `merge:"append"`
+			}{},
+			errMsg: "can only be used with slices",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().Parse(tt.config)
+			if err == nil {
+				t.Fatalf("Error expected, but there is none.")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Error %q should contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestUnsetValues(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	overlay := filepath.Join(dir, "overlay.yaml")
+	baseContent := `labels:
+  stage: dev
+  debug: "true"
+  team: core
+hosts: [a, b]
+tls:
+  cert: base.pem
+port: 8080
+`
+	overlayContent := `labels:
+  stage: prod
+  debug: ~
+hosts: null
+`
+	if err := os.WriteFile(base, []byte(baseContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overlay, []byte(overlayContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	type cfg struct {
+		Labels map[string]string
+		Hosts  []string
+		TLS    *struct {
+			Cert string
+		} `yaml:"tls"`
+		Port int
+	}
+
+	c := New()
+	c.WithProviders(&Yaml{Path: base}, &Yaml{Path: overlay}, &Env{Source: EnvMap{
+		"LABELS_TEAM": UnsetValue,
+		"TLS":         UnsetValue,
+		"PORT":        UnsetValue,
+	}})
+
+	conf := cfg{}
+	if err := c.Parse(&conf); err != nil {
+		t.Fatalf("%v\n", err)
+	}
+
+	expected := map[string]string{"stage": "prod"}
+	if !reflect.DeepEqual(conf.Labels, expected) {
+		t.Errorf("Value is '%v', but %v expected", conf.Labels, expected)
+	}
+	if len(conf.Hosts) != 0 {
+		t.Errorf("Value is '%v', but empty slice expected", conf.Hosts)
+	}
+	if conf.TLS != nil {
+		t.Errorf("Value is '%v', but nil expected", conf.TLS)
+	}
+	if conf.Port != 0 {
+		t.Errorf("Value is '%d', but %d expected", conf.Port, 0)
+	}
+}
+
+func TestUnsetUnexportedField(t *testing.T) {
+	type cfg struct {
+		Name   string
+		labels map[string]string
+	}
+
+	conf := cfg{labels: map[string]string{"debug": "true"}}
+	c := New()
+	c.WithProviders(&Env{Source: EnvMap{"LABELS_DEBUG": UnsetValue, "NAME": "app"}})
+	if err := c.Parse(&conf); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if conf.labels["debug"] != "true" {
+		t.Errorf("Value is '%v', but unexported field must not be changed", conf.labels)
+	}
+	if conf.Name != "app" {
+		t.Errorf("Value is '%s', but %q expected", conf.Name, "app")
+	}
+}
+
+func TestMergePointerStruct(t *testing.T) {
+	type tlsConfig struct {
+		Cert string
+		Key  string
+	}
+	type cfg struct {
+		TLS      *tlsConfig `yaml:"tls"`
+		Database *struct {
+			Host string
+		}
+	}
+
+	shared := &tlsConfig{Cert: "default.pem", Key: "default.key"}
+	c := New()
+	c.WithProviders(
+		&Map{Values: map[string]interface{}{"tls.cert": "yaml.pem", "tls.key": "yaml.key"}},
+		&Env{Source: EnvMap{"TLS_KEY": "env.key"}},
+	)
+
+	conf := cfg{TLS: shared}
+	if err := c.Parse(&conf); err != nil {
+		t.Fatalf("%v\n", err)
+	}
+
+	expected := tlsConfig{Cert: "yaml.pem", Key: "env.key"}
+	if conf.TLS == nil || *conf.TLS != expected {
+		t.Errorf("Value is '%v', but %v expected", conf.TLS, expected)
+	}
+	if shared.Cert != "default.pem" {
+		t.Errorf("Original pointer should not be modified, but value is '%s'", shared.Cert)
+	}
+	if conf.Database != nil {
+		t.Errorf("Value is '%v', but nil expected", conf.Database)
+	}
+}
+
+func TestMergeArray(t *testing.T) {
+	type cfg struct {
+		Names     [3]string
+		Endpoints [2]*struct {
+			Host string
+			Port int
+		}
+	}
+
+	c := New()
+	c.WithProviders(
+		&Map{Values: map[string]interface{}{
+			"names":     []string{"a", "b", "c"},
+			"endpoints": []map[string]interface{}{{"host": "a", "port": 1}, {"host": "b", "port": 2}},
+		}},
+		&Map{Values: map[string]interface{}{
+			"names.1":          "x",
+			"endpoints.0.port": 10,
+		}},
+	)
+
+	conf := cfg{}
+	if err := c.Parse(&conf); err != nil {
+		t.Fatalf("%v\n", err)
+	}
+
+	if conf.Names != [3]string{"a", "x", "c"} {
+		t.Errorf("Value is '%v', but %v expected", conf.Names, [3]string{"a", "x", "c"})
+	}
+	if conf.Endpoints[0].Host != "a" || conf.Endpoints[0].Port != 10 {
+		t.Errorf("Value is '%v', but a:10 expected", conf.Endpoints[0])
+	}
+	if conf.Endpoints[1].Host != "b" || conf.Endpoints[1].Port != 2 {
+		t.Errorf("Value is '%v', but b:2 expected", conf.Endpoints[1])
+	}
+}
+
+func TestEnvKeyPath(t *testing.T) {
+	type cfg struct {
+		Fleet struct {
+			Hosts []struct {
+				Profile struct {
+					Token string
+				}
+			}
+		}
+		Labels map[string]string `yaml:"labels"`
+		Nested map[string]struct {
+			Value string
+		}
+	}
+
+	tests := []struct {
+		key      string
+		expected []string
+	}{
+		{key: "APP_FLEET_HOSTS_0_PROFILE_TOKEN", expected: []string{"Fleet", "Hosts", "0", "Profile", "Token"}},
+		{key: "APP_LABELS_SOME_KEY", expected: []string{"labels", "some_key"}},
+		{key: "APP_NESTED_KEY_VALUE", expected: []string{"Nested", "key", "Value"}},
+		{key: "APP_FLEET_HOSTS_X", expected: nil},
+		{key: "OTHER_LABELS_KEY", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			path, ok := envKeyPath("APP", reflect.TypeOf(cfg{}), tt.key)
+			if ok != (tt.expected != nil) {
+				t.Fatalf("Resolved: %t, but %t expected", ok, tt.expected != nil)
+			}
+			if ok && !reflect.DeepEqual(path, tt.expected) {
+				t.Errorf("Value is '%v', but %v expected", path, tt.expected)
+			}
+		})
+	}
+}
+
 func assertProviderCount(t *testing.T, expected int, actual int) {
 	if actual != expected {
 		t.Fatalf("Configured providers: %d, but %d expected", actual, expected)
@@ -268,6 +641,20 @@ func (p *pMapOverride) Provide(config interface{}) error {
 	return nil
 }
 
+func (p *pSlow) Provide(config interface{}) error {
+	return p.ProvideContext(context.Background(), config)
+}
+
+func (p *pSlow) ProvideContext(ctx context.Context, config interface{}) error {
+	p.called = true
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
 func parse(config interface{}, cfg *testCfg) {
 	v := reflect.ValueOf(config).Elem()
 	vn := reflect.ValueOf(cfg).Elem()
@@ -279,3 +666,68 @@ func parse(config interface{}, cfg *testCfg) {
 		}
 	}
 }
+
+func TestOptionalProvider(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("intfield: text"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	valid := filepath.Join(dir, "valid.yaml")
+	if err := os.WriteFile(valid, []byte("stringfield:\nintfield: 5"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.yaml")
+
+	tests := []struct {
+		name      string
+		providers []Provider
+		expectErr bool
+	}{
+		{name: "Missing optional file", providers: []Provider{Optional(&Yaml{Path: missing})}},
+		{name: "Missing optional directory", providers: []Provider{Optional(&Dir{Path: missing})}},
+		{name: "Missing required file", providers: []Provider{&Yaml{Path: missing}}, expectErr: true},
+		{name: "Invalid optional file", providers: []Provider{Optional(&Yaml{Path: invalid})}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			c.WithProviders(tt.providers...)
+			err := c.Parse(&testCfg{})
+			if tt.expectErr && err == nil {
+				t.Fatalf("Error expected, but there is none.")
+			}
+			if !tt.expectErr && err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+		})
+	}
+
+	t.Run("Existing optional file", func(t *testing.T) {
+		c := New()
+		c.WithProviders(&pSimple{}, Optional(&Yaml{Path: valid}))
+		conf := testCfg{}
+		if err := c.Parse(&conf); err != nil {
+			t.Fatalf("No error expected, but was: %v\n", err)
+		}
+		if conf.IntField != 5 {
+			t.Errorf("Value is '%d', but %d expected", conf.IntField, 5)
+		}
+		// null value of optional yaml file still clears the value
+		if conf.StringField != "" {
+			t.Errorf("Value is '%s', but empty value expected", conf.StringField)
+		}
+	})
+}
+
+func TestDefaultWithoutYaml(t *testing.T) {
+	t.Setenv("STRINGFIELD", "from env")
+	conf := testCfg{}
+	if err := Parse(&conf); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if conf.StringField != "from env" {
+		t.Errorf("Value is '%s', but %q expected", conf.StringField, "from env")
+	}
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Consul is a provider for configuration using keys under a prefix in the Consul KV store.
+// Key hierarchy is mapped to the configuration struct, e.g. "app/database/host" with prefix "app" is mapped
+// to the Host field of the Database struct. Keys are matched to fields case-insensitively using the same names
+// as the Env provider, slice elements are addressed by index ("app/hosts/0") and map entries by key.
+type Consul struct {
+	// Address of the Consul HTTP API, CONSUL_HTTP_ADDR or "http://127.0.0.1:8500" is used if not set
+	Address string
+	// Prefix of keys used for configuration
+	Prefix string
+	// Token used for ACL, CONSUL_HTTP_TOKEN is used if not set
+	Token string
+	// Datacenter to query, datacenter of the agent is used if not set
+	Datacenter string
+	// Client used for requests, http.DefaultClient is used if not set
+	Client *http.Client
+	// WaitTime of blocking queries used by Watch, five minutes is used if not set
+	WaitTime time.Duration
+
+	mu    sync.Mutex
+	index uint64
+}
+
+type consulKV struct {
+	Key   string
+	Value []byte
+}
+
+// Provide loads configuration from Consul KV store
+func (c *Consul) Provide(config interface{}) error {
+	return c.ProvideContext(context.Background(), config)
+}
+
+// ProvideContext loads configuration from Consul KV store, the request is canceled when ctx is done
+func (c *Consul) ProvideContext(ctx context.Context, config interface{}) error {
+	pairs, index, err := c.list(ctx, 0)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.index = index
+	c.mu.Unlock()
+
+	prefix := strings.Trim(c.Prefix, "/")
+	values := map[string]string{}
+	for _, kv := range pairs {
+		key := strings.TrimPrefix(kv.Key, prefix)
+		// folders are keys with trailing slash and no value
+		if strings.HasSuffix(key, "/") && len(kv.Value) == 0 {
+			continue
+		}
+		values[key] = string(kv.Value)
+	}
+
+	nested, err := nestPaths(values, "/")
+	if err != nil {
+		return err
+	}
+	return assignValue("", nested, reflect.ValueOf(config).Elem())
+}
+
+// Watch uses blocking queries to notify when any key under the prefix changes.
+// Failed requests are retried after a second.
+func (c *Consul) Watch(ctx context.Context) (<-chan struct{}, error) {
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+		for {
+			c.mu.Lock()
+			last := c.index
+			c.mu.Unlock()
+
+			_, index, err := c.list(ctx, last)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+
+			c.mu.Lock()
+			// index going backwards means the state was reset, see Consul blocking queries documentation
+			if index < last {
+				index = 0
+			}
+			c.index = index
+			c.mu.Unlock()
+
+			if last != 0 && index != last {
+				select {
+				case ch <- struct{}{}:
+				default:
+					// notification is already pending
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// list reads all keys under the prefix. If index is not zero, blocking query is used to wait for changes.
+func (c *Consul) list(ctx context.Context, index uint64) ([]consulKV, uint64, error) {
+	query := url.Values{"recurse": []string{"true"}}
+	if c.Datacenter != "" {
+		query.Set("dc", c.Datacenter)
+	}
+	if index > 0 {
+		wait := c.WaitTime
+		if wait <= 0 {
+			wait = 5 * time.Minute
+		}
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", wait.String())
+	}
+
+	prefix := strings.Trim(c.Prefix, "/")
+	if prefix != "" {
+		// trailing slash prevents matching keys of sibling prefixes, e.g. "application" for "app"
+		prefix += "/"
+	}
+	u := strings.TrimSuffix(c.address(), "/") + "/v1/kv/" + escapeKeyPath(prefix) + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	token := c.Token
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+	if token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	newIndex, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		// no keys under the prefix
+		return nil, newIndex, nil
+	case http.StatusOK:
+	default:
+		return nil, 0, fmt.Errorf("consul: unexpected status %s", resp.Status)
+	}
+
+	var pairs []consulKV
+	if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, 0, fmt.Errorf("consul: %w", err)
+	}
+	return pairs, newIndex, nil
+}
+
+func (c *Consul) address() string {
+	addr := c.Address
+	if addr == "" {
+		addr = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+	if addr == "" {
+		addr = "127.0.0.1:8500"
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return addr
+}
+
+// escapeKeyPath escapes segments of slash-separated key, keeping the separators.
+func escapeKeyPath(key string) string {
+	parts := strings.Split(key, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
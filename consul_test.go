@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConsul implements the subset of the Consul KV HTTP API used by the Consul provider.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	kv      map[string]string
+	changed chan struct{}
+}
+
+func newFakeConsul(kv map[string]string) *fakeConsul {
+	return &fakeConsul{index: 1, kv: kv, changed: make(chan struct{})}
+}
+
+func (f *fakeConsul) set(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.kv[key] = value
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+
+	f.mu.Lock()
+	index, changed := f.index, f.changed
+	f.mu.Unlock()
+	if wait, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); wait >= index {
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var pairs []consulKV
+	for k, v := range f.kv {
+		if strings.HasPrefix(k, prefix) {
+			pairs = append(pairs, consulKV{Key: k, Value: []byte(v)})
+		}
+	}
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	if len(pairs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(pairs)
+}
+
+func TestConsul_Provide(t *testing.T) {
+	srv := httptest.NewServer(newFakeConsul(map[string]string{
+		"app/":                           "",
+		"app/stringfield":                "from consul",
+		"app/intfield":                   "42",
+		"app/nestedstruct/stringslice/0": "first",
+		"app/nestedstruct/stringslice/1": "second",
+		"app/labels/Team":                "core",
+		"application/stringfield":        "other app",
+	}))
+	defer srv.Close()
+
+	var cfg struct {
+		testCfg `yaml:",inline"`
+		Labels  map[string]string
+	}
+
+	c := Consul{Address: srv.URL, Prefix: "app", Token: "token"}
+	if err := c.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.StringField != "from consul" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "from consul")
+	}
+	if cfg.IntField != 42 {
+		t.Errorf("Value is '%d', but %d expected", cfg.IntField, 42)
+	}
+	if len(cfg.NestedStruct.StringSlice) != 2 || cfg.NestedStruct.StringSlice[1] != "second" {
+		t.Errorf("Value is '%v', but %v expected", cfg.NestedStruct.StringSlice, []string{"first", "second"})
+	}
+	if cfg.Labels["Team"] != "core" {
+		t.Errorf("Value is '%v', but %q expected", cfg.Labels, "core")
+	}
+}
+
+func TestConsulMissingPrefix(t *testing.T) {
+	srv := httptest.NewServer(newFakeConsul(map[string]string{}))
+	defer srv.Close()
+
+	c := Consul{Address: srv.URL, Prefix: "app", Token: "token"}
+	cfg := testCfg{}
+	if err := c.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+}
+
+func TestConsulUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(newFakeConsul(map[string]string{}))
+	defer srv.Close()
+
+	c := Consul{Address: srv.URL, Prefix: "app"}
+	if err := c.Provide(&testCfg{}); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+}
+
+func TestConsulWatch(t *testing.T) {
+	fake := newFakeConsul(map[string]string{"app/stringfield": "v1"})
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	c := Consul{Address: srv.URL, Prefix: "app", Token: "token", WaitTime: time.Second}
+	if err := c.Provide(&testCfg{}); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := c.Watch(ctx)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	fake.set("app/stringfield", "v2")
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Change notification expected")
+	}
+
+	cfg := testCfg{}
+	if err := c.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "v2" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "v2")
+	}
+
+	cancel()
+	for range changes {
+	}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SecretPattern is a regular expression matching names of fields, which are considered secrets.
+const SecretPattern = `(?i)(password|passwd|secret|token|credentials?|private_?key|api_?key)$`
+
+// MaskedValue replaces values of secrets reported by Diff.
+const MaskedValue = "******"
+
+var secretName = regexp.MustCompile(SecretPattern)
+
+// Change is a changed configuration value reported by Diff.
+type Change struct {
+	// Path of the value, dot-separated configuration field names, slice indices and map keys
+	Path string
+	// Old value, nil if the value was added
+	Old interface{}
+	// New value, nil if the value was removed
+	New interface{}
+}
+
+// Diff compares two configurations of the same type and returns changed values, ordered by the declaration
+// of fields and by map keys. Struct fields are named with the same names as environment variables, slice
+// elements by index and map entries by key. Values of secrets are replaced by MaskedValue. Secrets are fields
+// tagged with `secret:"true"` or a `vault` tag, or named as secrets (see SecretPattern), including all values
+// nested in them. Map entries with keys named as secrets are masked as well.
+func Diff(old, new interface{}) ([]Change, error) {
+	oldVal, newVal := reflect.ValueOf(old), reflect.ValueOf(new)
+	if !oldVal.IsValid() || !newVal.IsValid() || oldVal.Type() != newVal.Type() {
+		return nil, errors.New("configurations of the same type expected")
+	}
+	if t := oldVal.Type(); t.Kind() != reflect.Struct && (t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct) {
+		return nil, errors.New("configuration must be a struct or a pointer to struct")
+	}
+
+	var changes []Change
+	diffValues(nil, oldVal, newVal, false, func(path []string, o, n reflect.Value, secret bool) {
+		changes = append(changes, Change{
+			Path: strings.Join(path, "."),
+			Old:  changeValue(o, secret),
+			New:  changeValue(n, secret),
+		})
+	})
+	return changes, nil
+}
+
+func changeValue(v reflect.Value, secret bool) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if secret {
+		return MaskedValue
+	}
+	return v.Interface()
+}
+
+// isSecretField reports whether the field holds a secret.
+func isSecretField(tf reflect.StructField, name string) bool {
+	if tf.Tag.Get("vault") != "" || tf.Tag.Get("secret") == "true" {
+		return true
+	}
+	return secretName.MatchString(name) || secretName.MatchString(tf.Name)
+}
+
+// diffValues walks old and new values and calls fn with the path of each changed value. Struct fields are
+// named with the same names as environment variables, slice elements by index and map entries by key.
+// Added and removed scalar values are reported with an invalid value on the missing side, composite values
+// are compared with zero value.
+// Nil pointers to structs are compared as zero structs, the walk stops where both values are nil, so recursive
+// types are compared. Values nested in secret fields or map entries with secret keys are reported as secrets.
+func diffValues(path []string, old, new reflect.Value, secret bool, fn func(path []string, old, new reflect.Value, secret bool)) {
+	if isNilValue(old) && isNilValue(new) {
+		return
+	}
+	old, new = derefValue(old), derefValue(new)
+	// added and removed composite values are walked against zero value, so nested secrets are masked
+	switch {
+	case old.IsValid() && new.IsValid():
+	case old.IsValid() && isCompositeValue(old):
+		new = reflect.Zero(old.Type())
+	case new.IsValid() && isCompositeValue(new):
+		old = reflect.Zero(new.Type())
+	default:
+		if old.IsValid() || new.IsValid() {
+			fn(path, old, new, secret)
+		}
+		return
+	}
+	if old.Type() != new.Type() {
+		fn(path, old, new, secret)
+		return
+	}
+
+	switch kind := old.Kind(); {
+	case kind == reflect.Struct && isCompositeValue(old):
+		t := old.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tf := t.Field(i)
+			fieldName, ok := envFieldName(tf)
+			if !ok || !tf.IsExported() {
+				continue
+			}
+			next := path
+			if !isInlineField(tf) {
+				next = append(path[:len(path):len(path)], fieldName)
+			}
+			diffValues(next, old.Field(i), new.Field(i), secret || isSecretField(tf, fieldName), fn)
+		}
+	case kind == reflect.Slice || kind == reflect.Array:
+		n := old.Len()
+		if new.Len() > n {
+			n = new.Len()
+		}
+		for i := 0; i < n; i++ {
+			var o, v reflect.Value
+			if i < old.Len() {
+				o = old.Index(i)
+			}
+			if i < new.Len() {
+				v = new.Index(i)
+			}
+			diffValues(append(path[:len(path):len(path)], strconv.Itoa(i)), o, v, secret, fn)
+		}
+	case kind == reflect.Map:
+		keys := map[string]reflect.Value{}
+		for _, k := range old.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+		for _, k := range new.MapKeys() {
+			keys[fmt.Sprint(k.Interface())] = k
+		}
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			diffValues(append(path[:len(path):len(path)], name), old.MapIndex(keys[name]), new.MapIndex(keys[name]),
+				secret || secretName.MatchString(name), fn)
+		}
+	default:
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			fn(path, old, new, secret)
+		}
+	}
+}
+
+// derefValue dereferences pointers and interfaces. Nil pointers to structs are replaced by zero structs,
+// other nil values by invalid value.
+func derefValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+				return reflect.Zero(v.Type().Elem())
+			}
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isCompositeValue reports whether v is walked by diffValues. Structs without exported fields, e.g. time.Time,
+// are compared as single values.
+func isCompositeValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isNilValue reports whether v is invalid or a nil pointer or interface.
+func isNilValue(v reflect.Value) bool {
+	return !v.IsValid() || (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil()
+}
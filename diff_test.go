@@ -0,0 +1,143 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	type database struct {
+		Host     string
+		Password string
+	}
+	type cfg struct {
+		Name     string
+		Database *database `yaml:"db"`
+		Hosts    []string
+		Labels   map[string]string
+		Auth     struct {
+			User string
+			Key  string `secret:"true"`
+		} `secret:"true"`
+		Token string `vault:"secret/app#token"`
+	}
+
+	old := cfg{
+		Name:   "app",
+		Hosts:  []string{"a", "b"},
+		Labels: map[string]string{"team": "core", "stage": "dev"},
+		Token:  "t1",
+	}
+	new := old
+	new.Database = &database{Host: "db", Password: "p"}
+	new.Hosts = []string{"a", "c", "d"}
+	new.Labels = map[string]string{"team": "core", "zone": "eu", "DB_PASSWORD": "p"}
+	new.Auth.User = "admin"
+	new.Token = "t2"
+
+	changes, err := Diff(&old, &new)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	expected := []Change{
+		{Path: "db.Host", Old: "", New: "db"},
+		{Path: "db.Password", Old: MaskedValue, New: MaskedValue},
+		{Path: "Hosts.1", Old: "b", New: "c"},
+		{Path: "Hosts.2", Old: nil, New: "d"},
+		{Path: "Labels.DB_PASSWORD", Old: nil, New: MaskedValue},
+		{Path: "Labels.stage", Old: "dev", New: nil},
+		{Path: "Labels.zone", Old: nil, New: "eu"},
+		{Path: "Auth.User", Old: MaskedValue, New: MaskedValue},
+		{Path: "Token", Old: MaskedValue, New: MaskedValue},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Value is '%v', but %v expected", changes, expected)
+	}
+
+	changes, err = Diff(old, old)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Value is '%v', but no changes expected", changes)
+	}
+}
+
+func TestDiffAddedSecrets(t *testing.T) {
+	type user struct {
+		Name     string
+		Password string
+	}
+	type cfg struct {
+		Users  []user
+		ByName map[string]user
+		Since  time.Time
+	}
+	old := cfg{Users: []user{{Name: "a", Password: "p"}}}
+	new := cfg{
+		Users:  []user{{Name: "a", Password: "p"}, {Name: "b", Password: "hunter2"}},
+		ByName: map[string]user{"x": {Password: "s3cret"}},
+		Since:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	expected := []Change{
+		{Path: "Users.1.Name", Old: "", New: "b"},
+		{Path: "Users.1.Password", Old: MaskedValue, New: MaskedValue},
+		{Path: "ByName.x.Password", Old: MaskedValue, New: MaskedValue},
+		{Path: "Since", Old: time.Time{}, New: new.Since},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Value is '%v', but %v expected", changes, expected)
+	}
+
+	changes, err = Diff(new, old)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	for _, ch := range changes {
+		if strings.HasSuffix(ch.Path, "Password") && (ch.Old != MaskedValue || ch.New != MaskedValue) {
+			t.Errorf("Value is '%v', but %v expected", ch, MaskedValue)
+		}
+	}
+}
+
+func TestDiffRecursiveType(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+	old := node{Name: "a"}
+	new := node{Name: "a", Next: &node{Name: "b"}}
+
+	changes, err := Diff(old, old)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Value is '%v', but no changes expected", changes)
+	}
+
+	changes, err = Diff(old, new)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	expected := []Change{{Path: "Next.Name", Old: "", New: "b"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Value is '%v', but %v expected", changes, expected)
+	}
+}
+
+func TestDiffInvalid(t *testing.T) {
+	if _, err := Diff(&testCfg{}, testCfg{}); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	if _, err := Diff("a", "b"); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+}
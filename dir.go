@@ -0,0 +1,196 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dir is a provider for configuration using a directory where each file is a key, e.g. a mounted
+// Kubernetes ConfigMap or Secret volume. File names are split by dots into path segments, which are
+// mapped to the configuration struct with the same naming rules as the Env provider, e.g. "database.host",
+// "database..host" and "DATABASE_HOST" are all mapped to the Host field of the Database struct.
+// Files in subdirectories are mapped by their relative path the same way. Trailing line breaks are removed
+// from values.
+//
+// Kubernetes updates volumes atomically by swapping the "..data" symlink to a new timestamped directory.
+// If "..data" exists, files are read from its target, so a consistent snapshot is provided even while
+// the volume is being updated.
+type Dir struct {
+	// Path of the directory. If a relative path is provided, it is resolved relative to the
+	// application's executable directory, or to the root of FS.
+	Path string
+	// FS is the file system the directory is read from, the operating system is used if not set.
+	// Symlinks are not resolved in FS, so "..data" is read as a directory without the snapshot guarantee.
+	FS fs.FS
+	// Prefix of each key used for configuration, no prefix will be used if not set
+	Prefix string
+	// PollInterval of Watch, ten seconds is used if not set
+	PollInterval time.Duration
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// Provide loads configuration from files in the directory
+func (d *Dir) Provide(config interface{}) error {
+	_, err := d.ProvideUnset(config)
+	return err
+}
+
+// ProvideUnset loads configuration from files in the directory and returns paths of values set to UnsetValue
+func (d *Dir) ProvideUnset(config interface{}) ([][]string, error) {
+	values, err := d.read()
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.values = values
+	d.mu.Unlock()
+
+	env := envVars{
+		values: make(map[string]string, len(values)),
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env.set(key, values[key])
+	}
+	return provideEnv(d.Prefix, reflect.ValueOf(config), env)
+}
+
+// Watch polls the directory every PollInterval and notifies when any key changes after the last call of Provide.
+func (d *Dir) Watch(ctx context.Context) (<-chan struct{}, error) {
+	if d.Path == "" {
+		return nil, errors.New("path of configuration directory is not set")
+	}
+
+	interval := d.PollInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			values, err := d.read()
+			if err != nil {
+				continue
+			}
+			d.mu.Lock()
+			changed := d.values != nil && !maps.Equal(values, d.values)
+			d.values = values
+			d.mu.Unlock()
+			if !changed {
+				continue
+			}
+			select {
+			case ch <- struct{}{}:
+			default:
+				// notification is already pending
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// read reads all files in the directory and returns their values by env-style keys.
+func (d *Dir) read() (map[string]string, error) {
+	if d.Path == "" {
+		return nil, errors.New("path of configuration directory is not set")
+	}
+
+	fsys, root := d.FS, path.Clean(d.Path)
+	if fsys == nil {
+		dir, err := resolvePath(d.Path, "")
+		if err != nil {
+			return nil, err
+		}
+		// Kubernetes keeps the current snapshot in the directory the "..data" symlink points to
+		if target, err := filepath.EvalSymlinks(filepath.Join(dir, "..data")); err == nil {
+			dir = target
+		} else if dir, err = filepath.EvalSymlinks(dir); err != nil {
+			return nil, err
+		}
+		fsys, root = os.DirFS(dir), "."
+	} else if info, err := fs.Stat(fsys, path.Join(root, "..data")); err == nil && info.IsDir() {
+		root = path.Join(root, "..data")
+	}
+
+	values := map[string]string{}
+	err := fs.WalkDir(fsys, root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		// entries starting with ".." are internal to Kubernetes volumes, e.g. "..data" and timestamped directories
+		if strings.HasPrefix(entry.Name(), "..") {
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(p, root+"/")
+		if root == "." {
+			rel = p
+		}
+		values[dirKey(rel)] = strings.TrimRight(string(b), "\r\n")
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// dirKey converts relative path of a file to the name of environment variable, e.g. "database/pool..size"
+// to "DATABASE_POOL_SIZE".
+func dirKey(rel string) string {
+	var parts []string
+	for _, segment := range strings.Split(rel, "/") {
+		for _, part := range strings.Split(segment, ".") {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+
+	key := ""
+	for _, part := range parts {
+		key = joinPrefix(key, part)
+	}
+	return strings.ToUpper(key)
+}
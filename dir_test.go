@@ -0,0 +1,159 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// writeVolume writes files to a new timestamped directory and atomically swaps the "..data" symlink,
+// the same way Kubernetes updates ConfigMap and Secret volumes.
+func writeVolume(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+	if err := os.Mkdir(filepath.Join(dir, version), 0o755); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, version, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("No error expected, but was: %v\n", err)
+		}
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			if err := os.Symlink(filepath.Join("..data", name), link); err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+		}
+	}
+
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(version, tmp); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+}
+
+func TestDir_Provide(t *testing.T) {
+	dir := t.TempDir()
+	writeVolume(t, dir, "..2024_01_01", map[string]string{
+		"stringfield":                 "from dir\n",
+		"intField":                    "42",
+		"nestedstruct.stringslice..0": "first",
+		"NESTEDSTRUCT_STRINGSLICE_1":  "second",
+		"labels.team":                 "core",
+	})
+
+	var cfg struct {
+		testCfg `yaml:",inline"`
+		Labels  map[string]string
+	}
+	d := Dir{Path: dir}
+	if err := d.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.StringField != "from dir" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "from dir")
+	}
+	if cfg.IntField != 42 {
+		t.Errorf("Value is '%d', but %d expected", cfg.IntField, 42)
+	}
+	if len(cfg.NestedStruct.StringSlice) != 2 || cfg.NestedStruct.StringSlice[1] != "second" {
+		t.Errorf("Value is '%v', but %v expected", cfg.NestedStruct.StringSlice, []string{"first", "second"})
+	}
+	if cfg.Labels["team"] != "core" {
+		t.Errorf("Value is '%v', but %q expected", cfg.Labels, "core")
+	}
+}
+
+func TestDirPlain(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "nestedstruct"), 0o755); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	files := map[string]string{
+		"app.stringfield":              "plain",
+		"nestedstruct/app.nestedfield": "ignored",
+		"other":                        "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("No error expected, but was: %v\n", err)
+		}
+	}
+
+	cfg := testCfg{}
+	d := Dir{Path: dir, Prefix: "app"}
+	if err := d.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "plain" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "plain")
+	}
+}
+
+func TestDirMissing(t *testing.T) {
+	d := Dir{Path: filepath.Join(t.TempDir(), "missing")}
+	if err := d.Provide(&testCfg{}); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+}
+
+func TestDirWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeVolume(t, dir, "..v1", map[string]string{"stringfield": "v1", "intfield": "1"})
+
+	d := Dir{Path: dir, PollInterval: 10 * time.Millisecond}
+	if err := d.Provide(&testCfg{}); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := d.Watch(ctx)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	writeVolume(t, dir, "..v2", map[string]string{"stringfield": "v2", "intfield": "2"})
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Change notification expected")
+	}
+
+	cfg := testCfg{}
+	if err := d.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "v2" || cfg.IntField != 2 {
+		t.Errorf("Value is '%s, %d', but %q expected", cfg.StringField, cfg.IntField, "v2, 2")
+	}
+
+	cancel()
+	for range changes {
+	}
+}
+
+func TestDirFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/..data/stringfield":       {Data: []byte("from fs")},
+		"config/..data/intfield":          {Data: []byte("3\n")},
+		"config/..2024_01_01/stringfield": {Data: []byte("stale")},
+	}
+
+	cfg := testCfg{}
+	d := Dir{Path: "config", FS: fsys}
+	if err := d.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "from fs" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "from fs")
+	}
+	if cfg.IntField != 3 {
+		t.Errorf("Value is '%d', but %d expected", cfg.IntField, 3)
+	}
+}
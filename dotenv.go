@@ -0,0 +1,237 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// DotEnv is a provider for configuration using a .env file. Variables are mapped to the configuration
+// struct with the same naming rules as the Env provider, without modifying the process environment.
+type DotEnv struct {
+	// Path of the .env file. If a relative path is provided, it is resolved relative to the
+	// application's executable directory, or to the root of FS. ".env" is used if not set.
+	Path string
+	// FS is the file system the file is read from, e.g. embed.FS, the operating system is used if not set
+	FS fs.FS
+	// Prefix of each variable used for configuration, no prefix will be used if not set
+	Prefix string
+}
+
+// Provide loads configuration from .env file
+func (d *DotEnv) Provide(config interface{}) error {
+	_, err := d.ProvideUnset(config)
+	return err
+}
+
+// ProvideUnset loads configuration from .env file and returns paths of values set to UnsetValue
+func (d *DotEnv) ProvideUnset(config interface{}) ([][]string, error) {
+	b, p, err := readFile(d.FS, d.Path, ".env")
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := parseDotEnv(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+
+	return provideEnv(d.Prefix, reflect.ValueOf(config), env)
+}
+
+// parseDotEnv parses content of a .env file. Supported syntax:
+//   - comments starting with '#', blank lines and an optional "export" prefix
+//   - unquoted values, with trailing inline comments removed
+//   - single-quoted values, taken literally and possibly spanning multiple lines
+//   - double-quoted values, possibly spanning multiple lines, with \n, \r, \t, \", \\ and \$ escapes
+//   - ${VAR}, ${VAR:-default} and $VAR expansion in unquoted and double-quoted values, resolved from
+//     previously defined variables first and the process environment second
+func parseDotEnv(content string) (envVars, error) {
+	env := envVars{
+		values: make(map[string]string),
+	}
+	local := map[string]string{}
+	lookup := func(name string) (string, bool) {
+		if v, ok := local[name]; ok {
+			return v, true
+		}
+		return os.LookupEnv(name)
+	}
+
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	line := 1
+	for len(content) > 0 {
+		var stmt string
+		stmt, content = cutLine(content)
+		start := line
+		line++
+
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" || strings.HasPrefix(stmt, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(stmt, "export"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			stmt = strings.TrimSpace(rest)
+		}
+
+		key, raw, ok := strings.Cut(stmt, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !isDotEnvKey(key) {
+			return envVars{}, fmt.Errorf("line %d: invalid declaration %q", start, stmt)
+		}
+		raw = strings.TrimLeft(raw, " \t")
+
+		var value string
+		var err error
+		switch {
+		case strings.HasPrefix(raw, "'"):
+			value, content, line, err = readQuoted(raw[1:], content, line, '\'')
+		case strings.HasPrefix(raw, `"`):
+			value, content, line, err = readQuoted(raw[1:], content, line, '"')
+			if err == nil {
+				value = expandDotEnv(value, lookup, true)
+			}
+		default:
+			if pos := strings.Index(raw, " #"); pos != -1 {
+				raw = raw[:pos]
+			}
+			value = expandDotEnv(strings.TrimSpace(raw), lookup, false)
+		}
+		if err != nil {
+			return envVars{}, fmt.Errorf("line %d: %w", start, err)
+		}
+
+		local[key] = value
+		env.set(key, value)
+	}
+
+	return env, nil
+}
+
+func cutLine(s string) (string, string) {
+	if pos := strings.IndexByte(s, '\n'); pos != -1 {
+		return s[:pos], s[pos+1:]
+	}
+	return s, ""
+}
+
+// readQuoted reads a quoted value starting in first and continuing over the following lines of rest
+// until the closing quote. It returns the value, remaining content and the updated line number.
+func readQuoted(first, rest string, line int, quote byte) (string, string, int, error) {
+	var sb strings.Builder
+	current := first
+	for {
+		for i := 0; i < len(current); i++ {
+			c := current[i]
+			if c == '\\' && quote == '"' && i+1 < len(current) {
+				sb.WriteByte(c)
+				sb.WriteByte(current[i+1])
+				i++
+				continue
+			}
+			if c == quote {
+				tail := strings.TrimSpace(current[i+1:])
+				if tail != "" && !strings.HasPrefix(tail, "#") {
+					return "", "", line, fmt.Errorf("unexpected characters after closing quote: %q", tail)
+				}
+				return sb.String(), rest, line, nil
+			}
+			sb.WriteByte(c)
+		}
+		if rest == "" {
+			return "", "", line, fmt.Errorf("unterminated quoted value")
+		}
+		sb.WriteByte('\n')
+		current, rest = cutLine(rest)
+		line++
+	}
+}
+
+// expandDotEnv expands variables in the value. Escape sequences of double-quoted values are replaced
+// in the same pass if unescape is set, so an escaped backslash is not taken for an escape of the dollar sign.
+func expandDotEnv(s string, lookup func(string) (string, bool), unescape bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			if r, ok := dotEnvEscape(s[i+1], unescape); ok {
+				sb.WriteString(r)
+				i++
+				continue
+			}
+		}
+		if c != '$' || i+1 == len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+
+		if s[i+1] == '{' {
+			end := strings.IndexByte(s[i+2:], '}')
+			if end == -1 {
+				sb.WriteByte(c)
+				continue
+			}
+			expr := s[i+2 : i+2+end]
+			name, def, hasDef := strings.Cut(expr, ":-")
+			val, ok := lookup(name)
+			if (!ok || val == "") && hasDef {
+				val = def
+			}
+			sb.WriteString(val)
+			i += end + 2
+			continue
+		}
+
+		end := i + 1
+		for end < len(s) && isDotEnvNameChar(s[end]) {
+			end++
+		}
+		if end == i+1 {
+			sb.WriteByte(c)
+			continue
+		}
+		val, _ := lookup(s[i+1 : end])
+		sb.WriteString(val)
+		i = end - 1
+	}
+	return sb.String()
+}
+
+// dotEnvEscape returns the replacement of the escape sequence, escaped dollar sign is always replaced.
+func dotEnvEscape(c byte, unescape bool) (string, bool) {
+	if c == '$' {
+		return "$", true
+	}
+	if !unescape {
+		return "", false
+	}
+	switch c {
+	case 'n':
+		return "\n", true
+	case 'r':
+		return "\r", true
+	case 't':
+		return "\t", true
+	case '"', '\\':
+		return string(c), true
+	}
+	return "", false
+}
+
+func isDotEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if !isDotEnvNameChar(key[i]) && key[i] != '.' && key[i] != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDotEnvNameChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
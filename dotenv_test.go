@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	_ = os.Setenv("DOTENV_TEST_HOME", "/home/test")
+
+	content := `# comment
+PLAIN=value
+export EXPORTED=exported value
+SPACED = spaced   # inline comment
+SINGLE='literal $PLAIN \n'
+DOUBLE="line\tone\n\"quoted\" ${PLAIN}"
+MULTI="first
+second"
+EXPANDED=${PLAIN}-$EXPORTED
+FROM_OS=${DOTENV_TEST_HOME}/app
+DEFAULTED=${DOTENV_TEST_MISSING:-fallback}
+ESCAPED="\${PLAIN}"
+BACKSLASH="\\$PLAIN"
+EMPTY=
+`
+	env, err := parseDotEnv(content)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	expected := map[string]string{
+		"PLAIN":     "value",
+		"EXPORTED":  "exported value",
+		"SPACED":    "spaced",
+		"SINGLE":    `literal $PLAIN \n`,
+		"DOUBLE":    "line\tone\n\"quoted\" value",
+		"MULTI":     "first\nsecond",
+		"EXPANDED":  "value-exported value",
+		"FROM_OS":   "/home/test/app",
+		"DEFAULTED": "fallback",
+		"ESCAPED":   "${PLAIN}",
+		"BACKSLASH": `\value`,
+		"EMPTY":     "",
+	}
+	for k, v := range expected {
+		if env.values[k] != v {
+			t.Errorf("Value of %s is %q, but %q expected", k, env.values[k], v)
+		}
+	}
+	if len(env.keys) != len(expected) {
+		t.Errorf("Parsed %d keys, but %d expected", len(env.keys), len(expected))
+	}
+}
+
+func TestParseDotEnvInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "Missing separator", content: "KEY"},
+		{name: "Invalid key", content: "KEY WITH SPACE=val"},
+		{name: "Unterminated quote", content: "KEY=\"value\nnext"},
+		{name: "Trailing characters", content: "KEY='value' rest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseDotEnv(tt.content); err == nil {
+				t.Errorf("Error expected, but there is none.")
+			}
+		})
+	}
+}
+
+func TestDotEnv_Provide(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `APP_STRINGFIELD="from dotenv"
+APP_INTFIELD=42
+APP_NESTEDSTRUCT_STRINGSLICE_0=first
+APP_NESTEDSTRUCT_STRINGSLICE_1=second
+APP_MAPFIELD_BUILD=go build
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg struct {
+		testCfg  `yaml:",inline"`
+		MapField map[string]string
+	}
+
+	d := DotEnv{Path: path, Prefix: "APP"}
+	if err := d.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.StringField != "from dotenv" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "from dotenv")
+	}
+	if cfg.IntField != 42 {
+		t.Errorf("Value is '%d', but %d expected", cfg.IntField, 42)
+	}
+	if len(cfg.NestedStruct.StringSlice) != 2 || cfg.NestedStruct.StringSlice[1] != "second" {
+		t.Errorf("Value is '%v', but %v expected", cfg.NestedStruct.StringSlice, []string{"first", "second"})
+	}
+	if cfg.MapField["build"] != "go build" {
+		t.Errorf("Value is '%s', but %q expected", cfg.MapField["build"], "go build")
+	}
+	if _, ok := os.LookupEnv("APP_STRINGFIELD"); ok {
+		t.Errorf("Process environment should not be modified")
+	}
+}
+
+func TestDotEnvMissingFile(t *testing.T) {
+	d := DotEnv{Path: filepath.Join(t.TempDir(), ".env")}
+	cfg := testCfg{}
+	if err := d.Provide(&cfg); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+}
+
+func TestDotEnvFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env": {Data: []byte("APP_STRINGFIELD=from fs\nAPP_INTFIELD=7\n")},
+	}
+
+	cfg := testCfg{}
+	d := DotEnv{Prefix: "app", FS: fsys}
+	if err := d.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "from fs" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "from fs")
+	}
+	if cfg.IntField != 7 {
+		t.Errorf("Value is '%d', but %d expected", cfg.IntField, 7)
+	}
+}
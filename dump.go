@@ -0,0 +1,288 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Formats of configuration documents. Yaml and json are supported by the HTTP provider, all formats by Dump.
+const (
+	FormatYaml = "yaml"
+	FormatJSON = "json"
+	// FormatEnv are KEY=value lines with names of environment variables read by the Env provider
+	FormatEnv = "env"
+)
+
+// Dump serializes the configuration in the format. Yaml and json documents can be read by the Yaml provider,
+// environment variable names use the prefix of the first Env provider of c. Values of secrets are not masked.
+func (c *C) Dump(config interface{}, format string) ([]byte, error) {
+	switch format {
+	case FormatYaml:
+		return yaml.Marshal(dumpValue(reflect.ValueOf(config)))
+	case FormatJSON:
+		b, err := yaml.MarshalWithOptions(dumpValue(reflect.ValueOf(config)), yaml.JSON())
+		if err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, b, "", "  "); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	case FormatEnv:
+		v := reflect.ValueOf(config)
+		for v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return nil, errors.New("configuration must be a struct or a pointer to struct")
+		}
+		var out bytes.Buffer
+		if err := dumpEnv(&out, strings.ToUpper(c.envPrefix()), v); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// envPrefix returns the prefix of the first Env provider.
+func (c *C) envPrefix() string {
+	for _, p := range c.providers {
+		if o, ok := p.(*optionalProvider); ok {
+			p = o.Unwrap()
+		}
+		if e, ok := p.(*Env); ok {
+			return e.Prefix
+		}
+	}
+	return ""
+}
+
+// dumpValue converts structs nested in v to ordered mappings, so anonymous embedded structs are flattened
+// into the parent the same way as they are decoded by the Yaml provider. Other values and types implementing
+// marshalers are left to the yaml module.
+func dumpValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() && isMarshaler(v.Interface()) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return dumpValue(v.Elem())
+	case reflect.Struct:
+		return dumpStruct(v)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = dumpValue(v.Index(i))
+		}
+		return values
+	case reflect.Map:
+		if v.IsNil() {
+			return v.Interface()
+		}
+		elemType := reflect.TypeOf((*interface{})(nil)).Elem()
+		m := reflect.MakeMapWithSize(reflect.MapOf(v.Type().Key(), elemType), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.Zero(elemType)
+			if value := dumpValue(iter.Value()); value != nil {
+				elem = reflect.ValueOf(value)
+			}
+			m.SetMapIndex(iter.Key(), elem)
+		}
+		return m.Interface()
+	default:
+		return v.Interface()
+	}
+}
+
+// dumpStruct converts exported fields of the struct to mapping items named by yaml tags or lowercased field
+// names, fields of inline structs are flattened. Fields tagged with omitempty are omitted if empty.
+func dumpStruct(v reflect.Value) yaml.MapSlice {
+	items := yaml.MapSlice{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		parts := strings.Split(tf.Tag.Get("yaml"), ",")
+		if parts[0] == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		// exported fields of unexported embedded structs are decoded by the Yaml provider as well
+		if isInlineField(tf) {
+			if inline, ok := dumpValue(fv).(yaml.MapSlice); ok {
+				items = append(items, inline...)
+			}
+			continue
+		}
+		if !tf.IsExported() {
+			continue
+		}
+		if hasOption(parts[1:], "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		name := parts[0]
+		if name == "" {
+			name = strings.ToLower(tf.Name)
+		}
+		items = append(items, yaml.MapItem{Key: name, Value: dumpValue(fv)})
+	}
+	return items
+}
+
+// isMarshaler reports whether the value is marshaled by the yaml module using a marshaler.
+func isMarshaler(v interface{}) bool {
+	switch v.(type) {
+	case yaml.BytesMarshaler, yaml.BytesMarshalerContext, yaml.InterfaceMarshaler, yaml.InterfaceMarshalerContext,
+		encoding.TextMarshaler, time.Time, time.Duration:
+		return true
+	}
+	return false
+}
+
+// isEmptyValue reports whether the value is omitted by the omitempty option of the yaml module.
+func isEmptyValue(v reflect.Value) bool {
+	if z, ok := v.Interface().(yaml.IsZeroer); ok {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return true
+		}
+		return z.IsZero()
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && !isEmptyValue(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return v.IsZero()
+}
+
+func hasOption(options []string, option string) bool {
+	for _, opt := range options {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// dumpEnv writes KEY=value lines of all scalar values nested in v. Nil pointers and empty slices and maps
+// are omitted.
+func dumpEnv(out *bytes.Buffer, key string, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tf := t.Field(i)
+			fieldName, ok := envFieldName(tf)
+			if !ok || (!tf.IsExported() && !isInlineField(tf)) {
+				continue
+			}
+			next := key
+			if !isInlineField(tf) {
+				next = joinPrefix(key, strings.ToUpper(fieldName))
+			}
+			if err := dumpEnv(out, next, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := dumpEnv(out, joinPrefix(key, strconv.Itoa(i)), v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		elemType := v.Type().Elem()
+		keys := map[string]reflect.Value{}
+		names := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			name := strings.ToUpper(fmt.Sprint(k.Interface()))
+			keys[name] = k
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			elem := v.MapIndex(keys[name])
+			// values of interface maps are read as strings, see typeKeyPath
+			if elemType.Kind() == reflect.Interface && !elem.IsNil() && isComplexType(elem.Elem().Type()) {
+				return fmt.Errorf("%s: nested value can not be represented as environment variable",
+					joinPrefix(key, name))
+			}
+			if err := dumpEnv(out, joinPrefix(key, name), elem); err != nil {
+				return err
+			}
+		}
+	default:
+		value, err := envValue(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		fmt.Fprintf(out, "%s=%s\n", key, value)
+	}
+	return nil
+}
+
+// envValue formats the scalar value, so it is parsed back by the Env provider.
+func envValue(v reflect.Value) (string, error) {
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Bool:
+		s = strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			s = time.Duration(v.Int()).String()
+		} else {
+			s = strconv.FormatInt(v.Int(), 10)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		s = strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+	if strings.ContainsAny(s, "\r\n") {
+		return "", errors.New("multi-line value can not be represented as environment variable")
+	}
+	return s, nil
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type dumpServer struct {
+	Host string
+	Port int
+}
+
+type DumpOutput struct {
+	Format string
+}
+
+type dumpCfg struct {
+	Name    string
+	Timeout time.Duration
+	Debug   bool
+	Ratio   float64
+	Servers []dumpServer `yaml:"servers"`
+	Labels  map[string]string
+	Limits  map[string]*dumpServer
+	TLS     *struct {
+		Cert string
+	} `yaml:"tls"`
+	DumpOutput
+}
+
+func dumpTestCfg() dumpCfg {
+	return dumpCfg{
+		Name:       "app",
+		Timeout:    1500 * time.Millisecond,
+		Debug:      true,
+		Ratio:      0.25,
+		Servers:    []dumpServer{{Host: "a", Port: 80}, {Host: "b", Port: 81}},
+		Labels:     map[string]string{"team": "core", "stage": "dev"},
+		Limits:     map[string]*dumpServer{"eu": {Host: "c", Port: 82}},
+		DumpOutput: DumpOutput{Format: "json"},
+	}
+}
+
+func TestDumpEnv(t *testing.T) {
+	c := New()
+	c.WithProviders(Optional(&Yaml{}), &Env{Prefix: "APP"})
+	cfg := dumpTestCfg()
+
+	b, err := c.Dump(&cfg, FormatEnv)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	expected := `APP_NAME=app
+APP_TIMEOUT=1.5s
+APP_DEBUG=true
+APP_RATIO=0.25
+APP_SERVERS_0_HOST=a
+APP_SERVERS_0_PORT=80
+APP_SERVERS_1_HOST=b
+APP_SERVERS_1_PORT=81
+APP_LABELS_STAGE=dev
+APP_LABELS_TEAM=core
+APP_LIMITS_EU_HOST=c
+APP_LIMITS_EU_PORT=82
+APP_FORMAT=json
+`
+	if string(b) != expected {
+		t.Errorf("Value is '%s', but '%s' expected", b, expected)
+	}
+
+	env := EnvMap{}
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		k, v, _ := strings.Cut(line, "=")
+		env[k] = v
+	}
+	var parsed dumpCfg
+	if err := (&Env{Prefix: "APP", Source: env}).Provide(&parsed); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if !reflect.DeepEqual(parsed, cfg) {
+		t.Errorf("Value is '%+v', but %+v expected", parsed, cfg)
+	}
+}
+
+func TestDumpYamlJSON(t *testing.T) {
+	for _, format := range []string{FormatYaml, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			cfg := dumpTestCfg()
+			cfg.TLS = &struct{ Cert string }{Cert: "a.pem"}
+
+			b, err := New().Dump(cfg, format)
+			if err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+			var parsed dumpCfg
+			y := &Yaml{FS: fstest.MapFS{"config.yaml": {Data: b}}, Strict: true}
+			if err := y.Provide(&parsed); err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+			if !reflect.DeepEqual(parsed, cfg) {
+				t.Errorf("Value is '%+v', but %+v expected", parsed, cfg)
+			}
+		})
+	}
+}
+
+type dumpLogging struct {
+	Level string
+}
+
+func TestDumpUnexportedEmbedded(t *testing.T) {
+	type cfg struct {
+		dumpLogging
+		Name string
+	}
+	for _, format := range []string{FormatYaml, FormatJSON, FormatEnv} {
+		t.Run(format, func(t *testing.T) {
+			conf := cfg{dumpLogging: dumpLogging{Level: "debug"}, Name: "x"}
+			b, err := New().Dump(conf, format)
+			if err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+
+			var parsed cfg
+			if format == FormatEnv {
+				env := EnvMap{}
+				for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+					k, v, _ := strings.Cut(line, "=")
+					env[k] = v
+				}
+				err = (&Env{Source: env}).Provide(&parsed)
+			} else {
+				err = (&Yaml{FS: fstest.MapFS{"config.yaml": {Data: b}}, Strict: true}).Provide(&parsed)
+			}
+			if err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+			if !reflect.DeepEqual(parsed, conf) {
+				t.Errorf("Value is '%+v', but %+v expected", parsed, conf)
+			}
+		})
+	}
+}
+
+func TestDumpInvalid(t *testing.T) {
+	c := New()
+	if _, err := c.Dump(testCfg{}, "toml"); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	if _, err := c.Dump("value", FormatEnv); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	cfg := struct {
+		Values map[string]interface{}
+	}{Values: map[string]interface{}{"nested": map[string]interface{}{"a": 1}}}
+	if _, err := c.Dump(cfg, FormatEnv); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	multiLine := struct{ Value string }{Value: "a\nb"}
+	if _, err := c.Dump(multiLine, FormatEnv); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+}
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// KeySize is the size of keys used to encrypt values, in bytes.
+const KeySize = 32
+
+// encryptedValue matches an encrypted scalar value.
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,([^\]]*)\]$`)
+
+// GenerateKey returns a new random key encoded in base64, as expected in key files and environment variables.
+func GenerateKey() (string, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadKey reads base64 encoded key from the file, or from the environment variable if file is empty.
+// "~" and environment variables in the file path are expanded.
+func LoadKey(file, envVar string) ([]byte, error) {
+	var encoded string
+	switch {
+	case file != "":
+		p, err := expandPath(file)
+		if err != nil {
+			return nil, err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		encoded = string(b)
+	case envVar != "":
+		var ok bool
+		if encoded, ok = os.LookupEnv(envVar); !ok {
+			return nil, fmt.Errorf("key environment variable %s is not set", envVar)
+		}
+	default:
+		return nil, errors.New("key file or environment variable is not set")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key: %d bytes expected, but was %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// EncryptValue encrypts the value using AES-256-GCM. Type of the value (e.g. "str", "int", "bool" or "float")
+// is stored with the encrypted value, so it is decoded as the same yaml type after decryption. The dotted path
+// of keys of the value (without sequence indices) and the type are authenticated with the value, so it can not
+// be moved to another key or retyped without the key.
+func EncryptValue(key []byte, path, value, typ string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(value), additionalData(path, typ))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	enc := base64.StdEncoding
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		enc.EncodeToString(data), enc.EncodeToString(iv), enc.EncodeToString(tag), typ), nil
+}
+
+// DecryptValue decrypts the value encrypted by EncryptValue for the same path and returns the plain value
+// and its type.
+func DecryptValue(key []byte, path, value string) (string, string, error) {
+	m := encryptedValue.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return "", "", errors.New("invalid encrypted value")
+	}
+
+	parts := map[string]string{}
+	for _, field := range strings.Split(m[1], ",") {
+		name, value, _ := strings.Cut(field, ":")
+		parts[name] = value
+	}
+
+	var data, iv, tag []byte
+	for name, dst := range map[string]*[]byte{"data": &data, "iv": &iv, "tag": &tag} {
+		b, err := base64.StdEncoding.DecodeString(parts[name])
+		if err != nil {
+			return "", "", fmt.Errorf("invalid encrypted value: %s: %w", name, err)
+		}
+		*dst = b
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", "", err
+	}
+	if len(iv) != gcm.NonceSize() {
+		return "", "", errors.New("invalid encrypted value: iv")
+	}
+	typ := parts["type"]
+	plain, err := gcm.Open(nil, iv, append(data, tag...), additionalData(path, typ))
+	if err != nil {
+		return "", "", errors.New("value can not be decrypted, wrong key, modified value or value moved to another key")
+	}
+	return string(plain), typ, nil
+}
+
+// additionalData binds the encrypted value to its key path and type.
+func additionalData(path, typ string) []byte {
+	return []byte(path + "\x00" + typ)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptYaml replaces encrypted values in yaml document with plain values. Strings are replaced with
+// double-quoted scalars, so they are decoded as strings regardless of their content.
+func decryptYaml(b []byte, key []byte) ([]byte, error) {
+	return replaceScalars(b, func(s yamlScalar, _ string) (string, bool, error) {
+		value := s.node.GetToken().Value
+		if !encryptedValue.MatchString(value) {
+			return "", false, nil
+		}
+		plain, typ, err := DecryptValue(key, strings.Join(s.keys, "."), value)
+		if err != nil {
+			return "", false, fmt.Errorf("%s: %w", strings.Join(s.path, "."), err)
+		}
+		switch typ {
+		case "int", "float", "bool":
+			return plain, true, nil
+		}
+		quoted, err := json.Marshal(plain)
+		return string(quoted), true, err
+	})
+}
+
+// hasEncryptedValues reports whether yaml document contains encrypted scalar values.
+func hasEncryptedValues(b []byte) bool {
+	scalars, err := parseScalars(b)
+	if err != nil {
+		return false
+	}
+	for _, s := range scalars {
+		if encryptedValue.MatchString(s.node.GetToken().Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// EncryptYaml encrypts scalar values of yaml document, which are selected by match using the path of their
+// keys, and returns the modified document. Formatting and comments of the document are preserved.
+// Already encrypted values are skipped, block scalars and multi-line values are not supported.
+func EncryptYaml(b []byte, key []byte, match func(path []string) bool) ([]byte, error) {
+	return replaceScalars(b, func(s yamlScalar, text string) (string, bool, error) {
+		value := s.node.GetToken().Value
+		if !match(s.path) || encryptedValue.MatchString(value) {
+			return "", false, nil
+		}
+		if strings.Contains(text, "\n") {
+			return "", false, fmt.Errorf("%s: multi-line values are not supported", strings.Join(s.path, "."))
+		}
+		enc, err := EncryptValue(key, strings.Join(s.keys, "."), value, s.typ)
+		// quotes keep the value valid in flow collections, where commas separate entries
+		return `"` + enc + `"`, true, err
+	})
+}
+
+// RotateYaml re-encrypts all encrypted values of yaml document using the new key.
+func RotateYaml(b []byte, oldKey, newKey []byte) ([]byte, error) {
+	return replaceScalars(b, func(s yamlScalar, text string) (string, bool, error) {
+		value := s.node.GetToken().Value
+		if !encryptedValue.MatchString(value) {
+			return "", false, nil
+		}
+		path := strings.Join(s.keys, ".")
+		plain, typ, err := DecryptValue(oldKey, path, value)
+		if err != nil {
+			return "", false, fmt.Errorf("%s: %w", strings.Join(s.path, "."), err)
+		}
+		enc, err := EncryptValue(newKey, path, plain, typ)
+		quote := ""
+		if text[0] == '"' || text[0] == '\'' {
+			quote = text[:1]
+		}
+		return quote + enc + quote, true, err
+	})
+}
+
+// replaceScalars replaces single-line scalar values of yaml document with the result of fn, which receives
+// the scalar and its text in the document, and reports whether the value is replaced. The rest of the document,
+// including formatting and comments, is kept.
+func replaceScalars(b []byte, fn func(s yamlScalar, text string) (string, bool, error)) ([]byte, error) {
+	scalars, err := parseScalars(b)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.SplitAfter(string(b), "\n")
+	// replace from the end, so positions of preceding values on the same line are not shifted
+	for i := len(scalars) - 1; i >= 0; i-- {
+		s := scalars[i]
+		tk := s.node.GetToken()
+		text := strings.TrimSpace(tk.Origin)
+		replacement, ok, err := fn(s, text)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		line := []rune(lines[tk.Position.Line-1])
+		col := tk.Position.Column - 1
+		if col < 0 || col+len([]rune(text)) > len(line) || string(line[col:col+len([]rune(text))]) != text {
+			return nil, fmt.Errorf("%s: value not found at line %d", strings.Join(s.path, "."), tk.Position.Line)
+		}
+		lines[tk.Position.Line-1] = string(line[:col]) + replacement + string(line[col+len([]rune(text)):])
+	}
+
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// parseScalars parses yaml document and returns its scalar values in order.
+func parseScalars(b []byte) ([]yamlScalar, error) {
+	f, err := parser.ParseBytes(b, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var scalars []yamlScalar
+	for _, doc := range f.Docs {
+		collectScalars(doc.Body, nil, nil, &scalars)
+	}
+	return scalars, nil
+}
+
+type yamlScalar struct {
+	// path of keys and sequence indices
+	path []string
+	// keys of the path without sequence indices
+	keys []string
+	node ast.Node
+	typ  string
+}
+
+// collectScalars collects single-line scalar values of yaml node with paths of their keys.
+func collectScalars(node ast.Node, path, keys []string, scalars *[]yamlScalar) {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, v := range n.Values {
+			collectScalars(v, path, keys, scalars)
+		}
+	case *ast.MappingValueNode:
+		if _, ok := n.Key.(*ast.MergeKeyNode); ok {
+			return
+		}
+		key := n.Key.GetToken().Value
+		collectScalars(n.Value, append(path[:len(path):len(path)], key), append(keys[:len(keys):len(keys)], key), scalars)
+	case *ast.SequenceNode:
+		for i, v := range n.Values {
+			collectScalars(v, append(path[:len(path):len(path)], fmt.Sprint(i)), keys, scalars)
+		}
+	case *ast.StringNode:
+		*scalars = append(*scalars, yamlScalar{path: path, keys: keys, node: n, typ: "str"})
+	case *ast.IntegerNode:
+		*scalars = append(*scalars, yamlScalar{path: path, keys: keys, node: n, typ: "int"})
+	case *ast.FloatNode:
+		*scalars = append(*scalars, yamlScalar{path: path, keys: keys, node: n, typ: "float"})
+	case *ast.BoolNode:
+		*scalars = append(*scalars, yamlScalar{path: path, keys: keys, node: n, typ: "bool"})
+	}
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goccy/go-yaml"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	return key
+}
+
+func TestEncryptValue(t *testing.T) {
+	key := testKey(t)
+	enc, err := EncryptValue(key, "db.password", "s3cr3t: #1", "str")
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if !strings.HasPrefix(enc, "ENC[AES256_GCM,data:") || !strings.HasSuffix(enc, ",type:str]") {
+		t.Errorf("Value is '%s', but encrypted value expected", enc)
+	}
+
+	plain, typ, err := DecryptValue(key, "db.password", enc)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if plain != "s3cr3t: #1" || typ != "str" {
+		t.Errorf("Value is '%s, %s', but %q expected", plain, typ, "s3cr3t: #1, str")
+	}
+
+	if _, _, err := DecryptValue(testKey(t), "db.password", enc); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	tampered := strings.Replace(enc, "data:", "data:AA", 1)
+	if _, _, err := DecryptValue(key, "db.password", tampered); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	if _, _, err := DecryptValue(key, "name", enc); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	retyped := strings.Replace(enc, "type:str", "type:int", 1)
+	if _, _, err := DecryptValue(key, "db.password", retyped); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+}
+
+func TestEncryptYaml(t *testing.T) {
+	key := testKey(t)
+	content := `# database settings
+database:
+  host: localhost
+  password: "s3cr3t: yes"   # keep comment
+  port: 5432
+tokens: [{token: abc}, {token: 'def'}]
+`
+	enc, err := EncryptYaml([]byte(content), key, func(path []string) bool {
+		last := path[len(path)-1]
+		return last == "password" || last == "port" || last == "token"
+	})
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	out := string(enc)
+	for _, expected := range []string{"# database settings", "host: localhost", "# keep comment"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Value is '%s', but %q expected", out, expected)
+		}
+	}
+	var raw struct {
+		Database struct {
+			Host     string
+			Password string
+			Port     string
+		}
+		Tokens []struct {
+			Token string
+		}
+	}
+	if err := yaml.Unmarshal(enc, &raw); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if raw.Database.Host != "localhost" {
+		t.Errorf("Value is '%s', but %q expected", raw.Database.Host, "localhost")
+	}
+	if len(raw.Tokens) != 2 {
+		t.Fatalf("Value is '%v', but 2 tokens expected", raw.Tokens)
+	}
+	// each selected value is replaced by an encrypted scalar, which decrypts to the original value
+	for _, tt := range []struct{ value, path, plain string }{
+		{raw.Database.Password, "database.password", "s3cr3t: yes"},
+		{raw.Database.Port, "database.port", "5432"},
+		{raw.Tokens[0].Token, "tokens.token", "abc"},
+		{raw.Tokens[1].Token, "tokens.token", "def"},
+	} {
+		if !strings.HasPrefix(tt.value, "ENC[") || !strings.HasSuffix(tt.value, "]") {
+			t.Errorf("Value is '%s', but encrypted value expected", tt.value)
+			continue
+		}
+		plain, _, err := DecryptValue(key, tt.path, tt.value)
+		if err != nil {
+			t.Fatalf("No error expected, but was: %v\n", err)
+		}
+		if plain != tt.plain {
+			t.Errorf("Value is '%s', but %q expected", plain, tt.plain)
+		}
+	}
+
+	// already encrypted values are not encrypted again
+	again, err := EncryptYaml(enc, key, func(path []string) bool { return path[len(path)-1] != "host" })
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if string(again) != out {
+		t.Errorf("Value is '%s', but %q expected", again, out)
+	}
+
+	var cfg struct {
+		Database struct {
+			Host     string
+			Password string
+			Port     int
+		}
+		Tokens []struct {
+			Token string
+		}
+	}
+	t.Setenv("CONFIG_TEST_KEY", base64.StdEncoding.EncodeToString(key))
+	y := Yaml{FS: fstest.MapFS{"config.yaml": {Data: enc}}, KeyEnv: "CONFIG_TEST_KEY"}
+	if err := y.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.Database.Password != "s3cr3t: yes" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Database.Password, "s3cr3t: yes")
+	}
+	if cfg.Database.Port != 5432 {
+		t.Errorf("Value is '%d', but %d expected", cfg.Database.Port, 5432)
+	}
+	if len(cfg.Tokens) != 2 || cfg.Tokens[1].Token != "def" {
+		t.Errorf("Value is '%v', but %v expected", cfg.Tokens, []string{"abc", "def"})
+	}
+}
+
+func TestYamlEncryptedValueMoved(t *testing.T) {
+	key := testKey(t)
+	enc, err := EncryptYaml([]byte("password: hunter2\n"), key, func(path []string) bool { return true })
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	// encrypted password moved under a key, which is not a secret
+	moved := strings.Replace(string(enc), "password:", "stringfield:", 1)
+
+	t.Setenv("CONFIG_TEST_KEY", base64.StdEncoding.EncodeToString(key))
+	y := Yaml{FS: fstest.MapFS{"config.yaml": {Data: []byte(moved)}}, KeyEnv: "CONFIG_TEST_KEY"}
+	cfg := testCfg{}
+	if err := y.Provide(&cfg); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	if cfg.StringField != "" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "")
+	}
+}
+
+func TestYamlEncryptedValueInComment(t *testing.T) {
+	content := "# secrets look like ENC[AES256_GCM,data:...]\nstringfield: plain\n"
+	cfg := testCfg{}
+	if err := (&Yaml{FS: fstest.MapFS{"config.yaml": {Data: []byte(content)}}}).Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "plain" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "plain")
+	}
+}
+
+func TestRotateYaml(t *testing.T) {
+	oldKey, newKey := testKey(t), testKey(t)
+	enc, err := EncryptYaml([]byte("password: s3cr3t\nport: 1\n"), oldKey, func(path []string) bool { return true })
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	rotated, err := RotateYaml(enc, oldKey, newKey)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if _, err := decryptYaml(rotated, oldKey); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	plain, err := decryptYaml(rotated, newKey)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if string(plain) != "password: \"s3cr3t\"\nport: 1\n" {
+		t.Errorf("Value is '%s', but %q expected", plain, "password: \"s3cr3t\"\nport: 1\n")
+	}
+}
+
+func TestYamlEncryptedKeyFile(t *testing.T) {
+	key := testKey(t)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := EncryptValue(key, "stringfield", "from key file", "str")
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	fsys := fstest.MapFS{"config.yaml": {Data: []byte("stringfield: '" + enc + "'")}}
+
+	cfg := testCfg{}
+	if err := (&Yaml{FS: fsys, KeyFile: keyFile}).Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "from key file" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "from key file")
+	}
+
+	if err := (&Yaml{FS: fsys}).Provide(&testCfg{}); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+}
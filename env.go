@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -12,20 +14,208 @@ import (
 type Env struct {
 	// Prefix of each environment variable used for configuration, no prefix will be used if not set
 	Prefix string
+	// Source of environment variables, process environment will be used if not set
+	Source EnvSource
 }
 
+// EnvSource interface offers support for custom sources of environment variables.
+// Sources must be able to list all variables, so slice indices and map keys can be discovered.
+type EnvSource interface {
+
+	// Environ returns a copy of strings representing the environment, in the form "key=value".
+	Environ() []string
+}
+
+// EnvMap is an environment variable source backed by a map of variable names to values.
+type EnvMap map[string]string
+
+// Environ returns variables from the map in the form "key=value".
+func (m EnvMap) Environ() []string {
+	env := make([]string, 0, len(m))
+	for k, v := range m {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// EnvFunc is an environment variable source backed by a function with the same signature as os.Environ.
+type EnvFunc func() []string
+
+// Environ calls f.
+func (f EnvFunc) Environ() []string {
+	return f()
+}
+
+// UnsetValue is a value of environment variable, which clears the value configured by providers with
+// lower priority, e.g. LABELS_DEBUG=__unset__ deletes the "debug" entry of the Labels map.
+const UnsetValue = "__unset__"
+
 // Provide loads configuration from environment variables
 func (e *Env) Provide(config interface{}) error {
-	err := provide(e.Prefix, reflect.ValueOf(config))
+	_, err := e.ProvideUnset(config)
+	return err
+}
+
+// ProvideUnset loads configuration from environment variables and returns paths of values set to UnsetValue
+func (e *Env) ProvideUnset(config interface{}) ([][]string, error) {
+	source := e.Source
+	if source == nil {
+		source = EnvFunc(os.Environ)
+	}
+	return provideEnv(e.Prefix, reflect.ValueOf(config), readEnvVars(source))
+}
+
+// provideEnv maps variables from env to the configuration struct and returns paths of unset values.
+func provideEnv(prefix string, config reflect.Value, env envVars) ([][]string, error) {
+	unset := extractUnset(prefix, config.Type().Elem(), &env)
+
+	err := provide(prefix, config, env)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return unset, applyEnvOverrides(prefix, config, env)
+}
+
+// extractUnset removes variables set to UnsetValue from env and returns their configuration paths.
+func extractUnset(prefix string, t reflect.Type, env *envVars) [][]string {
+	var unset [][]string
+	keys := env.keys[:0:0]
+	for _, key := range env.keys {
+		if env.values[key] != UnsetValue {
+			keys = append(keys, key)
+			continue
+		}
+		delete(env.values, key)
+		if path, ok := envKeyPath(prefix, t, key); ok {
+			unset = append(unset, path)
+		}
+	}
+	env.keys = keys
+	return unset
+}
+
+// envKeyPath resolves name of environment variable to the path of configuration field names,
+// slice indices and map keys, using the same naming rules as the env provider.
+func envKeyPath(prefix string, t reflect.Type, key string) ([]string, bool) {
+	base := strings.ToUpper(prefix)
+	if base != "" && !strings.HasSuffix(base, "_") {
+		base += "_"
+	}
+	key = strings.ToUpper(key)
+	if !strings.HasPrefix(key, base) {
+		return nil, false
+	}
+	return typeKeyPath(t, key[len(base):])
+}
+
+// EnvNames returns names of environment variables read by the env provider with the prefix for the
+// configuration, in order of struct fields. Slice indices are represented by <N> and map keys by <KEY>.
+func EnvNames(prefix string, config interface{}) []string {
+	var names []string
+	typeEnvNames(strings.ToUpper(prefix), reflect.TypeOf(config), map[reflect.Type]bool{}, &names)
+	return names
+}
+
+func typeEnvNames(name string, t reflect.Type, visited map[reflect.Type]bool, names *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		// recursive types would produce infinite names
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+		for i := 0; i < t.NumField(); i++ {
+			tf := t.Field(i)
+			fieldName, ok := envFieldName(tf)
+			if !ok || (!tf.IsExported() && !isInlineField(tf)) {
+				continue
+			}
+			next := name
+			if !isInlineField(tf) {
+				next = joinPrefix(name, strings.ToUpper(fieldName))
+			}
+			typeEnvNames(next, tf.Type, visited, names)
+		}
+	case reflect.Slice, reflect.Array:
+		typeEnvNames(joinPrefix(name, "<N>"), t.Elem(), visited, names)
+	case reflect.Map:
+		if !isComplexType(t.Elem()) || t.Elem().Kind() == reflect.Interface {
+			*names = append(*names, joinPrefix(name, "<KEY>"))
+			return
+		}
+		typeEnvNames(joinPrefix(name, "<KEY>"), t.Elem(), visited, names)
+	default:
+		*names = append(*names, name)
+	}
+}
+
+func typeKeyPath(t reflect.Type, rest string) ([]string, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if rest == "" {
+		return nil, false
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			tf := t.Field(i)
+			fieldName, ok := envFieldName(tf)
+			if !ok {
+				continue
+			}
+			if isInlineField(tf) {
+				if path, ok := typeKeyPath(tf.Type, rest); ok {
+					return path, true
+				}
+				continue
+			}
+			name := strings.ToUpper(fieldName)
+			if rest == name {
+				return []string{fieldName}, true
+			}
+			if strings.HasPrefix(rest, name+"_") {
+				if path, ok := typeKeyPath(tf.Type, rest[len(name)+1:]); ok {
+					return append([]string{fieldName}, path...), true
+				}
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		idx, next, found := strings.Cut(rest, "_")
+		if n, err := strconv.Atoi(idx); err != nil || n < 0 {
+			return nil, false
+		}
+		if !found {
+			return []string{idx}, true
+		}
+		if path, ok := typeKeyPath(t.Elem(), next); ok {
+			return append([]string{idx}, path...), true
+		}
+	case reflect.Map:
+		if !isComplexType(t.Elem()) || t.Elem().Kind() == reflect.Interface {
+			return []string{strings.ToLower(rest)}, true
+		}
+		key, next, found := strings.Cut(rest, "_")
+		if !found {
+			return []string{strings.ToLower(key)}, true
+		}
+		if path, ok := typeKeyPath(t.Elem(), next); ok {
+			return append([]string{strings.ToLower(key)}, path...), true
+		}
 	}
 
-	env := readEnvVars()
-	return applyEnvOverrides(e.Prefix, reflect.ValueOf(config), env)
+	return nil, false
 }
 
-func provide(prefix string, config reflect.Value) error {
+func provide(prefix string, config reflect.Value, env envVars) error {
 	cfgVal := config.Elem()
 	tt := cfgVal.Type()
 
@@ -37,17 +227,30 @@ func provide(prefix string, config reflect.Value) error {
 			continue
 		}
 
-		if vf.Kind() == reflect.Struct {
+		if vf.Kind() == reflect.Struct || isStructPointer(vf.Type()) {
 			nextPrefix := prefix
-			if nextPrefix != "" && !strings.HasSuffix(nextPrefix, "_") {
-				nextPrefix += "_"
+			if !isInlineField(tf) {
+				if nextPrefix != "" && !strings.HasSuffix(nextPrefix, "_") {
+					nextPrefix += "_"
+				}
+				nextPrefix += fieldName
+			}
+			var err error
+			if vf.Kind() == reflect.Ptr {
+				if !env.hasPrefix(nextPrefix) {
+					continue
+				}
+				err = withStructPointer(vf, func(v reflect.Value) error {
+					return provide(nextPrefix, v, env)
+				})
+			} else {
+				err = provide(nextPrefix, vf.Addr(), env)
 			}
-			err := provide(nextPrefix+fieldName, vf.Addr())
 			if err != nil {
 				return err
 			}
 		} else {
-			err := parseValue(prefix, vf, tf)
+			err := parseValue(prefix, vf, tf, env)
 			if err != nil {
 				return err
 			}
@@ -57,13 +260,13 @@ func provide(prefix string, config reflect.Value) error {
 	return nil
 }
 
-func parseValue(prefix string, vField reflect.Value, tField reflect.StructField) error {
+func parseValue(prefix string, vField reflect.Value, tField reflect.StructField, env envVars) error {
 	fieldName, ok := envFieldName(tField)
 	if !ok {
 		return nil
 	}
 
-	if vField.Kind() == reflect.Slice || vField.Kind() == reflect.Map {
+	if vField.Kind() == reflect.Slice || vField.Kind() == reflect.Array || vField.Kind() == reflect.Map {
 		return nil
 	}
 
@@ -72,7 +275,7 @@ func parseValue(prefix string, vField reflect.Value, tField reflect.StructField)
 		envPrefix += "_"
 	}
 
-	envVal, ok := os.LookupEnv(envPrefix + strings.ToUpper(fieldName))
+	envVal, ok := env.values[envPrefix+strings.ToUpper(fieldName)]
 	if ok && envVal != "" && vField.CanSet() {
 		if err := processField(vField, envVal); err != nil {
 			return err
@@ -157,29 +360,94 @@ func envFieldName(tField reflect.StructField) (string, bool) {
 	return tField.Name, true
 }
 
+// isInlineField reports whether the fields of an embedded or `yaml:",inline"` struct
+// are flattened into the parent struct instead of being nested under the field name.
+func isInlineField(tField reflect.StructField) bool {
+	t := tField.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	parts := strings.Split(tField.Tag.Get("yaml"), ",")
+	for _, opt := range parts[1:] {
+		if opt == "inline" {
+			return true
+		}
+	}
+
+	return tField.Anonymous && parts[0] == ""
+}
+
+func isStructPointer(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
+
+// withStructPointer calls fn with a pointer to a copy of the struct referenced by vField, or a new struct
+// if vField is nil. vField is set to the copy only if it is not zero, so nil pointers are allocated lazily.
+func withStructPointer(vField reflect.Value, fn func(v reflect.Value) error) error {
+	if !vField.CanSet() {
+		return nil
+	}
+
+	v := reflect.New(vField.Type().Elem())
+	if !vField.IsNil() {
+		v.Elem().Set(vField.Elem())
+	}
+	if err := fn(v); err != nil {
+		return err
+	}
+
+	if !vField.IsNil() || !v.Elem().IsZero() {
+		vField.Set(v)
+	}
+	return nil
+}
+
 type envVars struct {
 	values map[string]string
 	keys   []string
 }
 
-func readEnvVars() envVars {
+func readEnvVars(source EnvSource) envVars {
 	env := envVars{
 		values: make(map[string]string),
 	}
-	for _, kv := range os.Environ() {
+	for _, kv := range source.Environ() {
 		parts := strings.SplitN(kv, "=", 2)
 		if len(parts) != 2 {
 			continue
 		}
-		key := strings.ToUpper(parts[0])
-		if _, ok := env.values[key]; !ok {
-			env.keys = append(env.keys, key)
-		}
-		env.values[key] = parts[1]
+		env.set(parts[0], parts[1])
 	}
 	return env
 }
 
+// hasPrefix reports whether any variable is named with the prefix. Pointers to structs are followed only
+// if they can be configured, so recursive types terminate.
+func (e *envVars) hasPrefix(prefix string) bool {
+	base := strings.ToUpper(prefix)
+	if base != "" && !strings.HasSuffix(base, "_") {
+		base += "_"
+	}
+	for _, key := range e.keys {
+		if strings.HasPrefix(key, base) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *envVars) set(key, value string) {
+	key = strings.ToUpper(key)
+	if _, ok := e.values[key]; !ok {
+		e.keys = append(e.keys, key)
+	}
+	e.values[key] = value
+}
+
 func applyEnvOverrides(prefix string, config reflect.Value, env envVars) error {
 	if config.Kind() != reflect.Ptr || config.IsNil() {
 		return nil
@@ -213,21 +481,50 @@ func applyOverrides(prefix string, v reflect.Value, setScalars bool, env envVars
 
 		switch vf.Kind() {
 		case reflect.Struct:
-			nextPrefix := joinPrefix(prefix, fieldName)
+			nextPrefix := prefix
+			if !isInlineField(tf) {
+				nextPrefix = joinPrefix(prefix, fieldName)
+			}
 			if err := applyOverrides(nextPrefix, vf, setScalars, env); err != nil {
 				return err
 			}
+		case reflect.Ptr:
+			if !isStructPointer(vf.Type()) {
+				if setScalars {
+					if err := parseValue(prefix, vf, tf, env); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			nextPrefix := prefix
+			if !isInlineField(tf) {
+				nextPrefix = joinPrefix(prefix, fieldName)
+			}
+			if !env.hasPrefix(nextPrefix) {
+				continue
+			}
+			err := withStructPointer(vf, func(v reflect.Value) error {
+				return applyOverrides(nextPrefix, v, setScalars, env)
+			})
+			if err != nil {
+				return err
+			}
 		case reflect.Slice:
 			if err := applySliceOverrides(prefix, fieldName, vf, tf, setScalars, env); err != nil {
 				return err
 			}
+		case reflect.Array:
+			if err := applyArrayValueOverrides(joinPrefix(prefix, fieldName), vf, env); err != nil {
+				return err
+			}
 		case reflect.Map:
 			if err := applyMapOverrides(prefix, fieldName, vf, tf, setScalars, env); err != nil {
 				return err
 			}
 		default:
 			if setScalars {
-				if err := parseValue(prefix, vf, tf); err != nil {
+				if err := parseValue(prefix, vf, tf, env); err != nil {
 					return err
 				}
 			}
@@ -321,6 +618,31 @@ func applySliceValueOverrides(base string, vField reflect.Value, env envVars, se
 		vField.Set(s)
 	}
 
+	return applyIndexOverrides(idxPrefix, vField, indices, env)
+}
+
+func applyArrayValueOverrides(base string, vField reflect.Value, env envVars) error {
+	if !vField.CanSet() {
+		return nil
+	}
+
+	idxPrefix := strings.ToUpper(base)
+	if !strings.HasSuffix(idxPrefix, "_") {
+		idxPrefix += "_"
+	}
+
+	indices := collectSliceIndices(idxPrefix, env.keys)
+	for idx := range indices {
+		if idx >= vField.Len() {
+			return fmt.Errorf("%s%d: index exceeds array length %d", idxPrefix, idx, vField.Len())
+		}
+	}
+
+	return applyIndexOverrides(idxPrefix, vField, indices, env)
+}
+
+// applyIndexOverrides applies overrides to slice or array elements at indices.
+func applyIndexOverrides(idxPrefix string, vField reflect.Value, indices map[int]struct{}, env envVars) error {
 	for idx := range indices {
 		elem := vField.Index(idx)
 		if elem.Kind() == reflect.Ptr && elem.IsNil() {
@@ -418,6 +740,8 @@ func applyValueOverrides(prefix string, vField reflect.Value, env envVars) error
 		return applyOverrides(prefix, vField, true, env)
 	case reflect.Slice:
 		return applySliceValueOverrides(prefix, vField, env, false)
+	case reflect.Array:
+		return applyArrayValueOverrides(prefix, vField, env)
 	case reflect.Map:
 		return applyMapValueOverrides(prefix, vField, env, false)
 	default:
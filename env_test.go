@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -221,6 +223,239 @@ func TestEnvConfigPointer(t *testing.T) {
 	}
 }
 
+func TestEnvConfigEmbeddedStructs(t *testing.T) {
+	_ = os.Setenv("SERVICE_LEVEL", "debug")
+	_ = os.Setenv("SERVICE_FORMAT", "json")
+	_ = os.Setenv("SERVICE_NAME", "api")
+	_ = os.Setenv("SERVICE_LABELS_TEAM", "core")
+
+	var cfg struct {
+		Service struct {
+			Logging
+			Output inlineOutput `yaml:",inline"`
+			Name   string
+		}
+	}
+
+	e := Env{}
+	if err := e.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.Service.Level != "debug" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Service.Level, "debug")
+	}
+	if cfg.Service.Output.Format != "json" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Service.Output.Format, "json")
+	}
+	if cfg.Service.Name != "api" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Service.Name, "api")
+	}
+	if cfg.Service.Labels["team"] != "core" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Service.Labels["team"], "core")
+	}
+}
+
+func TestEnvConfigStructPointer(t *testing.T) {
+	t.Parallel()
+
+	var cfg struct {
+		Database *struct {
+			Host  string
+			Pool  *struct{ Size int }
+			Hosts []string
+		}
+		Cache *struct {
+			Host string
+		}
+	}
+
+	e := Env{Source: EnvMap{
+		"DATABASE_HOST":      "db",
+		"DATABASE_POOL_SIZE": "5",
+		"DATABASE_HOSTS_0":   "replica",
+	}}
+	if err := e.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.Database == nil || cfg.Database.Host != "db" {
+		t.Fatalf("Value is '%v', but host %q expected", cfg.Database, "db")
+	}
+	if cfg.Database.Pool == nil || cfg.Database.Pool.Size != 5 {
+		t.Errorf("Value is '%v', but size %d expected", cfg.Database.Pool, 5)
+	}
+	if len(cfg.Database.Hosts) != 1 || cfg.Database.Hosts[0] != "replica" {
+		t.Errorf("Value is '%v', but %v expected", cfg.Database.Hosts, []string{"replica"})
+	}
+	if cfg.Cache != nil {
+		t.Errorf("Value is '%v', but nil expected", cfg.Cache)
+	}
+}
+
+func TestEnvConfigArray(t *testing.T) {
+	t.Parallel()
+
+	var cfg struct {
+		Names     [3]string
+		Endpoints [2]struct {
+			Host string
+			Port int
+		}
+	}
+	cfg.Names[2] = "default"
+
+	e := Env{Source: EnvMap{
+		"NAMES_0":          "first",
+		"ENDPOINTS_1_HOST": "localhost",
+		"ENDPOINTS_1_PORT": "8080",
+	}}
+	if err := e.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	expected := [3]string{"first", "", "default"}
+	if cfg.Names != expected {
+		t.Errorf("Value is '%v', but %v expected", cfg.Names, expected)
+	}
+	if cfg.Endpoints[1].Host != "localhost" || cfg.Endpoints[1].Port != 8080 {
+		t.Errorf("Value is '%v', but localhost:8080 expected", cfg.Endpoints[1])
+	}
+}
+
+func TestEnvConfigArrayIndexOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	var cfg struct {
+		Names [2]string
+	}
+
+	e := Env{Source: EnvMap{"NAMES_2": "third"}}
+	err := e.Provide(&cfg)
+	if err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	if !strings.Contains(err.Error(), "exceeds array length 2") {
+		t.Errorf("Error %q should report array length", err)
+	}
+}
+
+func TestEnvSourceMap(t *testing.T) {
+	t.Parallel()
+
+	e := Env{
+		Prefix: "SRC",
+		Source: EnvMap{
+			"SRC_STRINGFIELD":                "from map",
+			"SRC_INTFIELD":                   "7",
+			"SRC_NESTEDSTRUCT_STRINGSLICE_0": "first",
+			"SRC_NESTEDSTRUCT_STRINGSLICE_1": "second",
+			"STRINGFIELD":                    "without prefix",
+		},
+	}
+	cfg := testCfg{}
+	if err := e.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.StringField != "from map" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "from map")
+	}
+	if cfg.IntField != 7 {
+		t.Errorf("Value is '%d', but %d expected", cfg.IntField, 7)
+	}
+	if len(cfg.NestedStruct.StringSlice) != 2 || cfg.NestedStruct.StringSlice[1] != "second" {
+		t.Errorf("Value is '%v', but %v expected", cfg.NestedStruct.StringSlice, []string{"first", "second"})
+	}
+}
+
+func TestEnvSourceFunc(t *testing.T) {
+	t.Parallel()
+
+	e := Env{
+		Source: EnvFunc(func() []string {
+			return []string{"MAPFIELD_BUILD=go build", "MAPFIELD_TEST=go test"}
+		}),
+	}
+	var cfg struct {
+		MapField map[string]string
+	}
+	if err := e.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if len(cfg.MapField) != 2 {
+		t.Fatalf("Expected 2 map entries, got %d", len(cfg.MapField))
+	}
+	if cfg.MapField["test"] != "go test" {
+		t.Errorf("Value is '%v', but %q expected", cfg.MapField["test"], "go test")
+	}
+}
+
+func TestEnvConfigRecursiveType(t *testing.T) {
+	t.Parallel()
+
+	type node struct {
+		Name string
+		Next *node
+	}
+	e := Env{Prefix: "ROOT", Source: EnvMap{"ROOT_NAME": "a", "ROOT_NEXT_NEXT_NAME": "c"}}
+	var cfg node
+	if err := e.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.Name != "a" || cfg.Next == nil || cfg.Next.Next == nil || cfg.Next.Next.Name != "c" {
+		t.Fatalf("Value is '%+v', but nested nodes expected", cfg)
+	}
+	if cfg.Next.Name != "" || cfg.Next.Next.Next != nil {
+		t.Errorf("Value is '%+v', but unconfigured nodes expected", cfg.Next)
+	}
+}
+
+func TestEnvNames(t *testing.T) {
+	t.Parallel()
+
+	type node struct {
+		Name     string
+		Children []*node
+	}
+	var cfg struct {
+		Name         string
+		Logging      *Logging `yaml:"log"`
+		inlineOutput `yaml:",inline"`
+		Servers      []struct {
+			Host string
+		}
+		Weights [2]int
+		Tree    node
+		Ignored string `yaml:"-"`
+	}
+
+	names := EnvNames("app", &cfg)
+	expected := []string{
+		"APP_NAME",
+		"APP_LOG_LEVEL",
+		"APP_LOG_LABELS_<KEY>",
+		"APP_FORMAT",
+		"APP_SERVERS_<N>_HOST",
+		"APP_WEIGHTS_<N>",
+		"APP_TREE_NAME",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Value is '%v', but %v expected", names, expected)
+	}
+}
+
+type Logging struct {
+	Level  string
+	Labels map[string]string
+}
+
+type inlineOutput struct {
+	Format string
+}
+
 func setUpEnv(prefix string) {
 	p := ""
 	if prefix != "" {
@@ -0,0 +1,278 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Etcd is a provider for configuration using keys under a prefix in etcd v3, accessed via its JSON gateway.
+// Slash-separated key paths are mapped to the configuration struct, e.g. "/app/database/host" with prefix "/app"
+// is mapped to the Host field of the Database struct. Keys are matched to fields case-insensitively using the same
+// names as the Env provider, slice elements are addressed by index ("/app/hosts/0") and map entries by key.
+type Etcd struct {
+	// Endpoints of the etcd cluster, e.g. "http://127.0.0.1:2379", tried in order until a request succeeds
+	Endpoints []string
+	// Prefix of keys used for configuration
+	Prefix string
+	// Username and Password used for authentication, if set
+	Username string
+	Password string
+	// TLSConfig is used for https requests, ignored if Client is set
+	TLSConfig *tls.Config
+	// Client used for requests, a client with TLSConfig is created if not set
+	Client *http.Client
+
+	mu       sync.Mutex
+	client   *http.Client
+	revision int64
+}
+
+type etcdKV struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type etcdHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+type etcdRangeResponse struct {
+	Header etcdHeader `json:"header"`
+	Kvs    []etcdKV   `json:"kvs"`
+}
+
+type etcdWatchResponse struct {
+	Result struct {
+		Header   etcdHeader `json:"header"`
+		Canceled bool       `json:"canceled"`
+		Events   []struct {
+			Kv etcdKV `json:"kv"`
+		} `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Provide loads configuration from etcd
+func (e *Etcd) Provide(config interface{}) error {
+	return e.ProvideContext(context.Background(), config)
+}
+
+// ProvideContext loads configuration from etcd, the request is canceled when ctx is done
+func (e *Etcd) ProvideContext(ctx context.Context, config interface{}) error {
+	key, rangeEnd := e.keyRange()
+	var resp etcdRangeResponse
+	err := e.post(ctx, "/v3/kv/range", map[string]interface{}{
+		"key":       key,
+		"range_end": rangeEnd,
+	}, func(b *json.Decoder) error {
+		return b.Decode(&resp)
+	})
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.revision = resp.Header.Revision
+	e.mu.Unlock()
+
+	values := make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		values[strings.TrimPrefix(string(kv.Key), e.keyPrefix())] = string(kv.Value)
+	}
+	nested, err := nestPaths(values, "/")
+	if err != nil {
+		return err
+	}
+	return assignValue("", nested, reflect.ValueOf(config).Elem())
+}
+
+// Watch notifies when any key under the prefix changes after the last call of Provide.
+// Broken watch streams are reopened after a second.
+func (e *Etcd) Watch(ctx context.Context) (<-chan struct{}, error) {
+	if len(e.Endpoints) == 0 {
+		return nil, errors.New("etcd: no endpoints")
+	}
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+		for {
+			err := e.watch(ctx, ch)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (e *Etcd) watch(ctx context.Context, ch chan<- struct{}) error {
+	key, rangeEnd := e.keyRange()
+	e.mu.Lock()
+	start := e.revision + 1
+	e.mu.Unlock()
+
+	req := map[string]interface{}{
+		"create_request": map[string]interface{}{
+			"key":            key,
+			"range_end":      rangeEnd,
+			"start_revision": start,
+		},
+	}
+	return e.post(ctx, "/v3/watch", req, func(d *json.Decoder) error {
+		for {
+			var resp etcdWatchResponse
+			if err := d.Decode(&resp); err != nil {
+				return err
+			}
+			if resp.Error != nil {
+				return errors.New(resp.Error.Message)
+			}
+			if resp.Result.Canceled {
+				return errors.New("watch canceled")
+			}
+			if len(resp.Result.Events) == 0 {
+				continue
+			}
+
+			e.mu.Lock()
+			if resp.Result.Header.Revision > e.revision {
+				e.revision = resp.Result.Header.Revision
+			}
+			e.mu.Unlock()
+			select {
+			case ch <- struct{}{}:
+			default:
+				// notification is already pending
+			}
+		}
+	})
+}
+
+// keyPrefix returns the prefix with trailing slash, which prevents matching keys of sibling prefixes,
+// e.g. "/apps" for "/app".
+func (e *Etcd) keyPrefix() string {
+	if e.Prefix == "" || strings.HasSuffix(e.Prefix, "/") {
+		return e.Prefix
+	}
+	return e.Prefix + "/"
+}
+
+// keyRange returns the key and range end of all keys with the prefix.
+func (e *Etcd) keyRange() ([]byte, []byte) {
+	if e.Prefix == "" {
+		return []byte{0}, []byte{0}
+	}
+	key := []byte(e.keyPrefix())
+	end := make([]byte, len(key))
+	copy(end, key)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return key, end[:i+1]
+		}
+	}
+	// prefix consists of 0xff bytes only, range to the end of keyspace
+	return key, []byte{0}
+}
+
+// post sends the request to the endpoints in order, until the request succeeds, and decodes the response using fn.
+func (e *Etcd) post(ctx context.Context, path string, body interface{}, fn func(d *json.Decoder) error) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, endpoint := range e.Endpoints {
+		err := e.postEndpoint(ctx, strings.TrimSuffix(endpoint, "/"), path, b, fn)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return errors.New("etcd: no endpoints")
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Etcd) postEndpoint(ctx context.Context, endpoint, path string, body []byte, fn func(d *json.Decoder) error) error {
+	var token string
+	if e.Username != "" {
+		var auth struct {
+			Token string `json:"token"`
+		}
+		creds, err := json.Marshal(map[string]string{"name": e.Username, "password": e.Password})
+		if err != nil {
+			return err
+		}
+		err = e.do(ctx, endpoint+"/v3/auth/authenticate", creds, "", func(d *json.Decoder) error {
+			return d.Decode(&auth)
+		})
+		if err != nil {
+			return err
+		}
+		token = auth.Token
+	}
+
+	return e.do(ctx, endpoint+path, body, token, fn)
+}
+
+func (e *Etcd) do(ctx context.Context, url string, body []byte, token string, fn func(d *json.Decoder) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := e.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd: unexpected status %s from %s", resp.Status, url)
+	}
+	if err := fn(json.NewDecoder(resp.Body)); err != nil {
+		return fmt.Errorf("etcd: %w", err)
+	}
+	return nil
+}
+
+func (e *Etcd) httpClient() *http.Client {
+	if e.Client != nil {
+		return e.Client
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = e.TLSConfig
+		e.client = &http.Client{Transport: transport}
+	}
+	return e.client
+}
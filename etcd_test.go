@@ -0,0 +1,197 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEtcd implements the subset of the etcd v3 JSON gateway used by the Etcd provider.
+type fakeEtcd struct {
+	mu       sync.Mutex
+	revision int64
+	kv       map[string]string
+	changed  chan struct{}
+}
+
+func newFakeEtcd(kv map[string]string) *fakeEtcd {
+	return &fakeEtcd{revision: 1, kv: kv, changed: make(chan struct{})}
+}
+
+func (f *fakeEtcd) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.kv[key] = value
+	f.revision++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v3/auth/authenticate":
+		var creds map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&creds)
+		if creds["name"] != "root" || creds["password"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "token"})
+		return
+	}
+	if r.Header.Get("Authorization") != "token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		var req struct {
+			Key      []byte `json:"key"`
+			RangeEnd []byte `json:"range_end"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var kvs []etcdKV
+		for k, v := range f.kv {
+			if bytes.Compare([]byte(k), req.Key) >= 0 && bytes.Compare([]byte(k), req.RangeEnd) < 0 {
+				kvs = append(kvs, etcdKV{Key: []byte(k), Value: []byte(v)})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"header": map[string]string{"revision": strconv.FormatInt(f.revision, 10)},
+			"kvs":    kvs,
+		})
+	case "/v3/watch":
+		flusher := w.(http.Flusher)
+		enc := json.NewEncoder(w)
+		_ = enc.Encode(map[string]interface{}{"result": map[string]interface{}{"created": true}})
+		flusher.Flush()
+		for {
+			f.mu.Lock()
+			changed := f.changed
+			f.mu.Unlock()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-changed:
+			}
+			f.mu.Lock()
+			rev := f.revision
+			f.mu.Unlock()
+			_ = enc.Encode(map[string]interface{}{"result": map[string]interface{}{
+				"header": map[string]string{"revision": strconv.FormatInt(rev, 10)},
+				"events": []interface{}{map[string]interface{}{"kv": etcdKV{Key: []byte("changed")}}},
+			}})
+			flusher.Flush()
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestEtcd_Provide(t *testing.T) {
+	srv := httptest.NewServer(newFakeEtcd(map[string]string{
+		"/app/stringfield":                           "from etcd",
+		"/app/durfield":                              "3s",
+		"/app/nestedstruct/stringslice/0":            "first",
+		"/app/nestedstruct/anotherlevel/nestedint16": "-5",
+		"/apps/stringfield":                          "other app",
+	}))
+	defer srv.Close()
+
+	e := Etcd{Endpoints: []string{"http://127.0.0.1:1", srv.URL}, Prefix: "/app", Username: "root", Password: "secret"}
+	cfg := testCfg{}
+	if err := e.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.StringField != "from etcd" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "from etcd")
+	}
+	if cfg.DurField != 3*time.Second {
+		t.Errorf("Value is '%v', but %v expected", cfg.DurField, 3*time.Second)
+	}
+	if len(cfg.NestedStruct.StringSlice) != 1 || cfg.NestedStruct.StringSlice[0] != "first" {
+		t.Errorf("Value is '%v', but %v expected", cfg.NestedStruct.StringSlice, []string{"first"})
+	}
+	if cfg.NestedStruct.AnotherLevel.NestedInt16 != -5 {
+		t.Errorf("Value is '%d', but %d expected", cfg.NestedStruct.AnotherLevel.NestedInt16, -5)
+	}
+}
+
+func TestEtcdAuthenticationFailure(t *testing.T) {
+	srv := httptest.NewServer(newFakeEtcd(map[string]string{}))
+	defer srv.Close()
+
+	e := Etcd{Endpoints: []string{srv.URL}, Prefix: "/app/", Username: "root", Password: "wrong"}
+	if err := e.Provide(&testCfg{}); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+}
+
+func TestEtcdWatch(t *testing.T) {
+	fake := newFakeEtcd(map[string]string{"/app/stringfield": "v1"})
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	e := Etcd{Endpoints: []string{srv.URL}, Prefix: "/app/", Username: "root", Password: "secret"}
+	if err := e.Provide(&testCfg{}); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := e.Watch(ctx)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	fake.put("/app/stringfield", "v2")
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Change notification expected")
+	}
+
+	cfg := testCfg{}
+	if err := e.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "v2" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "v2")
+	}
+
+	cancel()
+	for range changes {
+	}
+}
+
+func TestEtcdKeyRange(t *testing.T) {
+	tests := []struct {
+		prefix string
+		key    []byte
+		end    []byte
+	}{
+		{prefix: "/app/", key: []byte("/app/"), end: []byte("/app0")},
+		{prefix: "/app", key: []byte("/app/"), end: []byte("/app0")},
+		{prefix: "a/\xff/", key: []byte("a/\xff/"), end: []byte("a/\xff0")},
+		{prefix: "", key: []byte{0}, end: []byte{0}},
+	}
+
+	for _, tt := range tests {
+		e := Etcd{Prefix: tt.prefix}
+		key, end := e.keyRange()
+		if !bytes.Equal(key, tt.key) || !bytes.Equal(end, tt.end) {
+			t.Errorf("Range of %q is [%q, %q), but [%q, %q) expected", tt.prefix, key, end, tt.key, tt.end)
+		}
+	}
+}
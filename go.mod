@@ -3,3 +3,9 @@ module github.com/tpodg/go-config
 go 1.21.0
 
 require github.com/goccy/go-yaml v1.19.1
+
+require (
+	golang.org/x/mod v0.20.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+	golang.org/x/tools v0.24.1
+)
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP is a provider for configuration using yaml or json document fetched from a URL.
+// Format is detected from the Content-Type header of the response or the extension of the URL path,
+// unless it is set explicitly. Responses are cached by ETag, so unchanged documents are not transferred again.
+type HTTP struct {
+	// URL of the configuration document
+	URL string
+	// Format of the document (FormatYaml or FormatJSON), detected from the response if not set
+	Format string
+	// Profiles are active profiles selecting documents by their "profile" or "when" key, the same as in Yaml
+	Profiles []string
+	// Header contains additional request headers
+	Header http.Header
+	// BearerToken is sent in the Authorization header if set
+	BearerToken string
+	// TLSConfig is used for https requests, ignored if Client is set
+	TLSConfig *tls.Config
+	// Client used for requests, a client with TLSConfig is created if not set
+	Client *http.Client
+	// PollInterval of Watch, one minute is used if not set
+	PollInterval time.Duration
+
+	mu     sync.Mutex
+	client *http.Client
+	etag   string
+	body   []byte
+}
+
+// Provide loads configuration from the URL
+func (h *HTTP) Provide(config interface{}) error {
+	return h.ProvideContext(context.Background(), config)
+}
+
+// ProvideContext loads configuration from the URL, the request is canceled when ctx is done
+func (h *HTTP) ProvideContext(ctx context.Context, config interface{}) error {
+	_, err := h.ProvideUnsetContext(ctx, config)
+	return err
+}
+
+// ProvideUnset loads configuration from the URL and returns paths of values explicitly set to null
+func (h *HTTP) ProvideUnset(config interface{}) ([][]string, error) {
+	return h.ProvideUnsetContext(context.Background(), config)
+}
+
+// ProvideUnsetContext loads configuration from the URL and returns paths of values explicitly set to null,
+// the request is canceled when ctx is done
+func (h *HTTP) ProvideUnsetContext(ctx context.Context, config interface{}) ([][]string, error) {
+	b, _, err := h.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	unset, err := decodeYaml(b, config, h.Profiles, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", h.URL, err)
+	}
+	return unset, nil
+}
+
+// Watch polls the URL every PollInterval and notifies when the document changes.
+// Failed requests are retried in the next interval.
+func (h *HTTP) Watch(ctx context.Context) (<-chan struct{}, error) {
+	if h.URL == "" {
+		return nil, errors.New("URL of configuration document is not set")
+	}
+
+	interval := h.PollInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			_, changed, err := h.fetch(ctx)
+			if err != nil || !changed {
+				continue
+			}
+			select {
+			case ch <- struct{}{}:
+			default:
+				// notification is already pending
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// fetch requests the document, using the cached body if it was not modified.
+// It reports whether the document changed since the previous request.
+func (h *HTTP) fetch(ctx context.Context) ([]byte, bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	for k, v := range h.Header {
+		req.Header[k] = v
+	}
+	if h.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.BearerToken)
+	}
+	if h.etag != "" {
+		req.Header.Set("If-None-Match", h.etag)
+	}
+
+	resp, err := h.httpClient().Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if h.body == nil {
+			return nil, false, fmt.Errorf("%s: not modified, but no cached response", h.URL)
+		}
+		return h.body, false, nil
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, false, fmt.Errorf("%s: unexpected status %s: %w", h.URL, resp.Status, fs.ErrNotExist)
+	default:
+		return nil, false, fmt.Errorf("%s: unexpected status %s", h.URL, resp.Status)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := h.detectFormat(resp); err != nil {
+		return nil, false, err
+	}
+
+	changed := h.body != nil && !bytes.Equal(b, h.body)
+	h.etag = resp.Header.Get("ETag")
+	h.body = b
+	return b, changed, nil
+}
+
+func (h *HTTP) httpClient() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+	if h.client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = h.TLSConfig
+		h.client = &http.Client{Transport: transport}
+	}
+	return h.client
+}
+
+// detectFormat checks that the format of the document is supported. Both formats are decoded as yaml,
+// because json is valid yaml, but responses with other content types, e.g. html error pages, are rejected.
+func (h *HTTP) detectFormat(resp *http.Response) error {
+	switch h.Format {
+	case FormatYaml, FormatJSON:
+		return nil
+	case "":
+	default:
+		return fmt.Errorf("unsupported format %q", h.Format)
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") ||
+			strings.HasSuffix(mediaType, "/yaml") || strings.HasSuffix(mediaType, "/x-yaml") ||
+			strings.HasSuffix(mediaType, "+yaml") {
+			return nil
+		}
+	}
+
+	switch strings.ToLower(path.Ext(resp.Request.URL.Path)) {
+	case ".json", ".yaml", ".yml":
+		return nil
+	}
+
+	return fmt.Errorf("%s: unsupported content type %q", h.URL, resp.Header.Get("Content-Type"))
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTP_Provide(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		expectErr   bool
+	}{
+		{
+			name:        "JSON by content type",
+			path:        "/config",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"stringfield": "from json", "nestedstruct": {"stringslice": ["a", "b"]}}`,
+		},
+		{
+			name:        "YAML by content type",
+			path:        "/config",
+			contentType: "application/yaml",
+			body:        "stringfield: from yaml\nnestedstruct:\n  stringslice: [a, b]\n",
+		},
+		{
+			name: "YAML by extension",
+			path: "/config.yml",
+			body: "stringfield: from extension\nnestedstruct:\n  stringslice: [a, b]\n",
+		},
+		{
+			name:        "Unsupported content type",
+			path:        "/config",
+			contentType: "text/html",
+			body:        "<html></html>",
+			expectErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				} else {
+					w.Header().Set("Content-Type", "text/plain")
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			h := HTTP{URL: srv.URL + tt.path}
+			cfg := testCfg{}
+			err := h.Provide(&cfg)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Provide() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if !strings.HasPrefix(cfg.StringField, "from ") {
+				t.Errorf("Value is '%s', but value from document expected", cfg.StringField)
+			}
+			if len(cfg.NestedStruct.StringSlice) != 2 {
+				t.Errorf("Value is '%v', but %v expected", cfg.NestedStruct.StringSlice, []string{"a", "b"})
+			}
+		})
+	}
+}
+
+func TestHTTPUnsetValues(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte("mapfield:\n  debug: ~\n  stage: prod\n"))
+	}))
+	defer srv.Close()
+
+	var cfg struct {
+		MapField map[string]string
+	}
+	c := New()
+	c.WithProviders(&Map{Values: map[string]interface{}{"mapfield.debug": "true", "mapfield.team": "core"}},
+		&HTTP{URL: srv.URL})
+	if err := c.ParseContext(context.Background(), &cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	expected := map[string]string{"team": "core", "stage": "prod"}
+	if !reflect.DeepEqual(cfg.MapField, expected) {
+		t.Errorf("Value is '%v', but %v expected", cfg.MapField, expected)
+	}
+}
+
+func TestHTTPProfiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte("profile: prod\nstringfield: remote\n"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		profiles []string
+		expected string
+	}{
+		{"Active", []string{"prod"}, "remote"},
+		{"Inactive", []string{"dev"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := HTTP{URL: srv.URL, Profiles: tt.profiles}
+			cfg := testCfg{}
+			if err := h.Provide(&cfg); err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+			if cfg.StringField != tt.expected {
+				t.Errorf("Value is '%s', but %q expected", cfg.StringField, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHTTPHeadersAndETag(t *testing.T) {
+	var requests, notModified int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Env") != "prod" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/x-yaml")
+		_, _ = w.Write([]byte("stringfield: secured"))
+	}))
+	defer srv.Close()
+
+	h := HTTP{
+		URL:         srv.URL,
+		Header:      http.Header{"X-Env": []string{"prod"}},
+		BearerToken: "secret",
+		TLSConfig:   srv.Client().Transport.(*http.Transport).TLSClientConfig,
+	}
+
+	for i := 0; i < 2; i++ {
+		cfg := testCfg{}
+		if err := h.Provide(&cfg); err != nil {
+			t.Fatalf("No error expected, but was: %v\n", err)
+		}
+		if cfg.StringField != "secured" {
+			t.Errorf("Value is '%s', but %q expected", cfg.StringField, "secured")
+		}
+	}
+	if atomic.LoadInt32(&requests) != 2 || atomic.LoadInt32(&notModified) != 1 {
+		t.Errorf("Requests: %d, not modified: %d, but 2 and 1 expected", requests, notModified)
+	}
+}
+
+func TestHTTPUnexpectedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	h := HTTP{URL: srv.URL + "/config.yaml"}
+	err := h.Provide(&testCfg{})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("Error with status expected, but was: %v", err)
+	}
+}
+
+func TestHTTPWatch(t *testing.T) {
+	var version atomic.Value
+	version.Store("v1")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := version.Load().(string)
+		if r.Header.Get("If-None-Match") == v {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", v)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"stringfield": "` + v + `"}`))
+	}))
+	defer srv.Close()
+
+	h := HTTP{URL: srv.URL, PollInterval: 5 * time.Millisecond}
+	if err := h.Provide(&testCfg{}); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := h.Watch(ctx)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	version.Store("v2")
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("Change notification expected")
+	}
+
+	cfg := testCfg{}
+	if err := h.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "v2" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "v2")
+	}
+
+	cancel()
+	for range changes {
+	}
+}
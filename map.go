@@ -0,0 +1,416 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Map is a provider for configuration using in-memory values, e.g. values computed at startup or
+// bridged from another configuration system.
+// Values can be nested maps or flat dotted paths ("fleet.hosts.0.profile.token"), both can be mixed.
+// Keys are matched to fields case-insensitively using the same names as the Env provider,
+// slice elements are addressed by index and scalar values are converted to the field type.
+type Map struct {
+	Values map[string]interface{}
+}
+
+// Provide loads configuration from in-memory values
+func (m *Map) Provide(config interface{}) error {
+	values, err := expandPaths(m.Values)
+	if err != nil {
+		return err
+	}
+
+	cfgVal := reflect.ValueOf(config)
+	if err := validateConfig(cfgVal); err != nil {
+		return err
+	}
+
+	return assignValue("", values, cfgVal.Elem())
+}
+
+// expandPaths converts dotted keys to nested maps, merging them with nested maps provided directly.
+func expandPaths(values map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(values))
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		val := values[key]
+		if nested, ok := val.(map[string]interface{}); ok {
+			expanded, err := expandPaths(nested)
+			if err != nil {
+				return nil, err
+			}
+			val = expanded
+		}
+
+		parts := strings.Split(key, ".")
+		current := out
+		for i, part := range parts[:len(parts)-1] {
+			next, ok := current[part]
+			if !ok {
+				m := map[string]interface{}{}
+				current[part] = m
+				current = m
+				continue
+			}
+			m, ok := next.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("conflicting values for key %q", strings.Join(parts[:i+1], "."))
+			}
+			current = m
+		}
+
+		last := parts[len(parts)-1]
+		if err := mergePathValue(current, last, val); err != nil {
+			return nil, fmt.Errorf("conflicting values for key %q", key)
+		}
+	}
+
+	return out, nil
+}
+
+// nestPaths converts keys of hierarchical key-value stores, e.g. "app/database/host", to nested maps.
+// Segments of keys are split by sep, empty segments are ignored.
+func nestPaths(values map[string]string, sep string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var parts []string
+		for _, part := range strings.Split(key, sep) {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		current := out
+		for i, part := range parts[:len(parts)-1] {
+			next, ok := current[part]
+			if !ok {
+				m := map[string]interface{}{}
+				current[part] = m
+				current = m
+				continue
+			}
+			m, ok := next.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("conflicting values for key %q", strings.Join(parts[:i+1], sep))
+			}
+			current = m
+		}
+		if err := mergePathValue(current, parts[len(parts)-1], values[key]); err != nil {
+			return nil, fmt.Errorf("conflicting values for key %q", key)
+		}
+	}
+
+	return out, nil
+}
+
+func mergePathValue(m map[string]interface{}, key string, val interface{}) error {
+	existing, ok := m[key]
+	if !ok {
+		m[key] = val
+		return nil
+	}
+	dst, dstOk := existing.(map[string]interface{})
+	src, srcOk := val.(map[string]interface{})
+	if !dstOk || !srcOk {
+		return fmt.Errorf("conflicting values for key %q", key)
+	}
+	for k, v := range src {
+		if err := mergePathValue(dst, k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignValue assigns source value to target, converting it to the target type.
+func assignValue(path string, source interface{}, target reflect.Value) error {
+	if source == nil {
+		return nil
+	}
+	if target.Kind() == reflect.Struct {
+		return assignStruct(path, reflect.ValueOf(source), target)
+	}
+	if !target.CanSet() {
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return assignValue(path, source, target.Elem())
+	case reflect.Slice:
+		return assignSlice(path, reflect.ValueOf(source), target)
+	case reflect.Array:
+		return assignArray(path, reflect.ValueOf(source), target)
+	case reflect.Map:
+		return assignMap(path, reflect.ValueOf(source), target)
+	default:
+		if err := assignScalar(reflect.ValueOf(source), target); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	}
+}
+
+func assignStruct(path string, source reflect.Value, target reflect.Value) error {
+	if source.Kind() != reflect.Map || source.Type().Key().Kind() != reflect.String {
+		return assignError(path, source, target)
+	}
+
+	t := target.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		vf := target.Field(i)
+		fieldName, ok := envFieldName(tf)
+		if !ok {
+			continue
+		}
+
+		if isInlineField(tf) {
+			if err := assignValue(path, source.Interface(), vf); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, key := range matchingKeys(source, fieldName) {
+			if err := assignValue(joinPath(path, key.String()), source.MapIndex(key).Interface(), vf); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchingKeys returns sorted keys of the map which match name case-insensitively.
+func matchingKeys(m reflect.Value, name string) []reflect.Value {
+	var keys []reflect.Value
+	for _, k := range m.MapKeys() {
+		if strings.EqualFold(k.String(), name) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+func assignSlice(path string, source reflect.Value, target reflect.Value) error {
+	switch source.Kind() {
+	case reflect.Slice, reflect.Array:
+		s := reflect.MakeSlice(target.Type(), source.Len(), source.Len())
+		for i := 0; i < source.Len(); i++ {
+			if err := assignValue(joinPath(path, strconv.Itoa(i)), source.Index(i).Interface(), s.Index(i)); err != nil {
+				return err
+			}
+		}
+		target.Set(s)
+		return nil
+	case reflect.Map:
+		if source.Type().Key().Kind() != reflect.String {
+			break
+		}
+		indices := map[int]reflect.Value{}
+		maxIdx := -1
+		for _, key := range source.MapKeys() {
+			idx, err := strconv.Atoi(key.String())
+			if err != nil || idx < 0 {
+				return fmt.Errorf("%s: invalid slice index %q", path, key.String())
+			}
+			indices[idx] = source.MapIndex(key)
+			if idx > maxIdx {
+				maxIdx = idx
+			}
+		}
+		if target.Len() <= maxIdx {
+			s := reflect.MakeSlice(target.Type(), maxIdx+1, maxIdx+1)
+			reflect.Copy(s, target)
+			target.Set(s)
+		}
+		for idx, val := range indices {
+			if err := assignValue(joinPath(path, strconv.Itoa(idx)), val.Interface(), target.Index(idx)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return assignError(path, source, target)
+}
+
+func assignArray(path string, source reflect.Value, target reflect.Value) error {
+	elements := map[int]reflect.Value{}
+	switch source.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < source.Len(); i++ {
+			elements[i] = source.Index(i)
+		}
+	case reflect.Map:
+		if source.Type().Key().Kind() != reflect.String {
+			return assignError(path, source, target)
+		}
+		for _, key := range source.MapKeys() {
+			idx, err := strconv.Atoi(key.String())
+			if err != nil || idx < 0 {
+				return fmt.Errorf("%s: invalid array index %q", path, key.String())
+			}
+			elements[idx] = source.MapIndex(key)
+		}
+	default:
+		return assignError(path, source, target)
+	}
+
+	for idx, val := range elements {
+		if idx >= target.Len() {
+			return fmt.Errorf("%s: index exceeds array length %d", joinPath(path, strconv.Itoa(idx)), target.Len())
+		}
+		if err := assignValue(joinPath(path, strconv.Itoa(idx)), val.Interface(), target.Index(idx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func assignMap(path string, source reflect.Value, target reflect.Value) error {
+	if source.Kind() != reflect.Map {
+		return assignError(path, source, target)
+	}
+	if target.IsNil() {
+		target.Set(reflect.MakeMapWithSize(target.Type(), source.Len()))
+	}
+
+	keyType := target.Type().Key()
+	for _, key := range source.MapKeys() {
+		k := reflect.New(keyType).Elem()
+		if err := assignScalar(key, k); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		elem := reflect.New(target.Type().Elem()).Elem()
+		if existing := target.MapIndex(k); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := assignValue(joinPath(path, fmt.Sprint(key.Interface())), source.MapIndex(key).Interface(), elem); err != nil {
+			return err
+		}
+		target.SetMapIndex(k, elem)
+	}
+
+	return nil
+}
+
+func assignScalar(source reflect.Value, target reflect.Value) error {
+	if source.Kind() == reflect.Interface {
+		source = source.Elem()
+	}
+	if source.Type().AssignableTo(target.Type()) {
+		target.Set(source)
+		return nil
+	}
+	if isNumberKind(source.Kind()) && isNumberKind(target.Kind()) {
+		return assignNumber(source, target)
+	}
+	if isComplexType(source.Type()) {
+		return fmt.Errorf("cannot assign %s to %s", source.Type(), target.Type())
+	}
+
+	return processField(target, fmt.Sprint(source.Interface()))
+}
+
+// assignNumber converts the number to the target type. Numbers which overflow the target type and fractional
+// numbers assigned to integers are rejected, the same as when parsed by strconv.
+func assignNumber(source reflect.Value, target reflect.Value) error {
+	var f float64
+	switch source.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(source.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(source.Uint())
+	default:
+		f = source.Float()
+	}
+
+	var overflow bool
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case source.Kind() >= reflect.Int && source.Kind() <= reflect.Int64:
+			overflow = target.OverflowInt(source.Int())
+		case source.Kind() >= reflect.Uint && source.Kind() <= reflect.Uint64:
+			overflow = source.Uint() > math.MaxInt64 || target.OverflowInt(int64(source.Uint()))
+		default:
+			if f != math.Trunc(f) {
+				return fmt.Errorf("cannot assign fractional number %v to %s", f, target.Type())
+			}
+			overflow = f < math.MinInt64 || f >= math.MaxInt64 || target.OverflowInt(int64(f))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch {
+		case source.Kind() >= reflect.Int && source.Kind() <= reflect.Int64:
+			overflow = source.Int() < 0 || target.OverflowUint(uint64(source.Int()))
+		case source.Kind() >= reflect.Uint && source.Kind() <= reflect.Uint64:
+			overflow = target.OverflowUint(source.Uint())
+		default:
+			if f != math.Trunc(f) {
+				return fmt.Errorf("cannot assign fractional number %v to %s", f, target.Type())
+			}
+			overflow = f < 0 || f >= math.MaxUint64 || target.OverflowUint(uint64(f))
+		}
+	default:
+		overflow = target.OverflowFloat(f)
+	}
+	if overflow {
+		return fmt.Errorf("value %v out of range for %s", source.Interface(), target.Type())
+	}
+
+	target.Set(source.Convert(target.Type()))
+	return nil
+}
+
+func assignError(path string, source reflect.Value, target reflect.Value) error {
+	return fmt.Errorf("%s: cannot assign %s to %s", path, source.Type(), target.Type())
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// joinPath joins dotted key path segments, used to report errors.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
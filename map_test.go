@@ -0,0 +1,193 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type mapProviderCfg struct {
+	Fleet struct {
+		Hosts []struct {
+			Name    string
+			Port    int
+			Profile struct {
+				Token string
+			}
+		}
+	}
+	Labels  map[string]string
+	Timeout time.Duration
+	Ratio   float64
+	Enabled *bool `yaml:"enabled"`
+}
+
+func TestMap_ProvideNested(t *testing.T) {
+	m := Map{Values: map[string]interface{}{
+		"fleet": map[string]interface{}{
+			"hosts": []interface{}{
+				map[string]interface{}{"name": "a", "port": 8080},
+				map[string]interface{}{"name": "b", "port": "8081"},
+			},
+		},
+		"labels":  map[string]interface{}{"stage": "dev"},
+		"timeout": "5s",
+		"ratio":   1,
+		"enabled": "true",
+	}}
+
+	cfg := mapProviderCfg{}
+	if err := m.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if len(cfg.Fleet.Hosts) != 2 {
+		t.Fatalf("Expected 2 hosts, got %d", len(cfg.Fleet.Hosts))
+	}
+	if cfg.Fleet.Hosts[1].Port != 8081 {
+		t.Errorf("Value is '%d', but %d expected", cfg.Fleet.Hosts[1].Port, 8081)
+	}
+	if cfg.Labels["stage"] != "dev" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Labels["stage"], "dev")
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("Value is '%v', but %v expected", cfg.Timeout, 5*time.Second)
+	}
+	if cfg.Ratio != 1 {
+		t.Errorf("Value is '%f', but %f expected", cfg.Ratio, 1.0)
+	}
+	if cfg.Enabled == nil || !*cfg.Enabled {
+		t.Errorf("Value is '%v', but %t expected", cfg.Enabled, true)
+	}
+}
+
+func TestMap_ProvideDottedPaths(t *testing.T) {
+	m := Map{Values: map[string]interface{}{
+		"fleet.hosts.1.profile.token": "token-1",
+		"fleet.hosts.0.name":          "a",
+		"Labels.Stage":                "dev",
+		"labels":                      map[string]interface{}{"team": "core"},
+	}}
+
+	cfg := mapProviderCfg{}
+	if err := m.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if len(cfg.Fleet.Hosts) != 2 {
+		t.Fatalf("Expected 2 hosts, got %d", len(cfg.Fleet.Hosts))
+	}
+	if cfg.Fleet.Hosts[0].Name != "a" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Fleet.Hosts[0].Name, "a")
+	}
+	if cfg.Fleet.Hosts[1].Profile.Token != "token-1" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Fleet.Hosts[1].Profile.Token, "token-1")
+	}
+	if cfg.Labels["Stage"] != "dev" || cfg.Labels["team"] != "core" {
+		t.Errorf("Value is '%v', but map with Stage and team expected", cfg.Labels)
+	}
+}
+
+func TestMap_ProvideErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		errMsg string
+	}{
+		{
+			name:   "Invalid scalar",
+			values: map[string]interface{}{"fleet.hosts.0.port": "http"},
+			errMsg: "fleet.hosts.0.port",
+		},
+		{
+			name:   "Invalid index",
+			values: map[string]interface{}{"fleet.hosts.first.port": 1},
+			errMsg: "invalid slice index",
+		},
+		{
+			name:   "Conflicting keys",
+			values: map[string]interface{}{"labels": "x", "labels.stage": "dev"},
+			errMsg: "conflicting values",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Map{Values: tt.values}
+			cfg := mapProviderCfg{}
+			err := m.Provide(&cfg)
+			if err == nil {
+				t.Fatalf("Error expected, but there is none.")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Error %q should contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestMap_ProvideNumbers(t *testing.T) {
+	type numbers struct {
+		Small uint8
+		Count int
+		Size  uint
+		Ratio float32
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		errMsg string
+	}{
+		{"Valid", map[string]interface{}{"small": 255, "count": 3.0, "size": uint64(1), "ratio": 0.5}, ""},
+		{"Overflow", map[string]interface{}{"small": 300}, "small: value 300 out of range for uint8"},
+		{"Fraction", map[string]interface{}{"count": 3.9}, "count: cannot assign fractional number 3.9 to int"},
+		{"Negative unsigned", map[string]interface{}{"size": -1}, "size: value -1 out of range for uint"},
+		{"Float overflow", map[string]interface{}{"ratio": 1e300}, "ratio: value 1e+300 out of range for float32"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := numbers{}
+			err := (&Map{Values: tt.values}).Provide(&cfg)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Fatalf("No error expected, but was: %v\n", err)
+				}
+				expected := numbers{Small: 255, Count: 3, Size: 1, Ratio: 0.5}
+				if cfg != expected {
+					t.Errorf("Value is '%+v', but %+v expected", cfg, expected)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Error expected, but there is none.")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Error %q should contain %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestMapWithOtherProviders(t *testing.T) {
+	c := New()
+	c.WithProviders(&pFull{}, &Map{Values: map[string]interface{}{
+		"stringfield":                          "from map",
+		"nestedstruct.anotherlevel.uint8field": 7,
+	}})
+
+	cfg := testCfg{}
+	if err := c.Parse(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.StringField != "from map" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "from map")
+	}
+	if cfg.IntField != 123 {
+		t.Errorf("Value is '%d', but %d expected", cfg.IntField, 123)
+	}
+	if cfg.NestedStruct.AnotherLevel.Uint8Field != 7 {
+		t.Errorf("Value is '%d', but %d expected", cfg.NestedStruct.AnotherLevel.Uint8Field, 7)
+	}
+}
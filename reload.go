@@ -0,0 +1,72 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+)
+
+// Reloader reloads the configuration store when the process receives one of the signals, SIGHUP by default
+// on unix systems.
+// All providers are run again and the configuration is replaced only if the parsing and validation succeed,
+// otherwise the previous configuration is kept. Results are logged with paths of changed values.
+type Reloader[T any] struct {
+	// Store which is reloaded
+	Store *Store[T]
+	// Signals triggering the reload, SIGHUP is used if not set on unix systems, other systems have no default
+	Signals []os.Signal
+	// Logger used to report reloads, slog.Default() is used if not set
+	Logger *slog.Logger
+}
+
+// Run reloads the store on each signal until ctx is done.
+func (r *Reloader[T]) Run(ctx context.Context) {
+	signals := r.Signals
+	if len(signals) == 0 {
+		signals = defaultSignals
+	}
+	if len(signals) == 0 {
+		// Notify without signals would relay all incoming signals
+		<-ctx.Done()
+		return
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-ch:
+			r.reload(ctx, sig)
+		}
+	}
+}
+
+func (r *Reloader[T]) reload(ctx context.Context, sig os.Signal) {
+	logger := r.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	old, cfg, err := r.Store.reload(ctx)
+	if err != nil {
+		logger.Error("configuration reload failed, previous configuration is kept",
+			"signal", sig.String(), "error", err)
+		return
+	}
+
+	changes, err := Diff(old, cfg)
+	if err != nil {
+		logger.Error("configuration reloaded, but changes can not be compared", "signal", sig.String(), "error", err)
+		return
+	}
+	changed := make([]string, len(changes))
+	for i, c := range changes {
+		changed[i] = c.Path
+	}
+	logger.Info("configuration reloaded", "signal", sig.String(), "changed", changed)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package config
+
+import "os"
+
+// defaultSignals trigger the reload if Reloader.Signals are not set. There is no conventional reload signal
+// on this platform, so signals must be set explicitly.
+var defaultSignals []os.Signal
@@ -0,0 +1,127 @@
+//go:build unix
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// pLocked is a provider with values, which can be changed while the configuration is reloaded.
+type pLocked struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+func (p *pLocked) set(values map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.values = values
+}
+
+func (p *pLocked) Provide(config interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return (&Map{Values: p.values}).Provide(config)
+}
+
+type reloadCfg struct {
+	Name   string
+	Port   int
+	Labels map[string]string
+}
+
+func (c *reloadCfg) Validate() error {
+	if c.Port < 0 {
+		return errors.New("port must not be negative")
+	}
+	return nil
+}
+
+func TestReloader(t *testing.T) {
+	m := &pLocked{values: map[string]interface{}{"name": "a", "port": 80, "labels.team": "core"}}
+	c := New()
+	c.WithProviders(m)
+	s, err := NewStore(c, reloadCfg{})
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	// the signal must not terminate the test process before the reloader is listening
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, syscall.SIGUSR1)
+	defer signal.Stop(ignored)
+
+	var logs syncBuffer
+	r := Reloader[reloadCfg]{
+		Store:   s,
+		Signals: []os.Signal{syscall.SIGUSR1},
+		Logger:  slog.New(slog.NewTextHandler(&logs, nil)),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+
+	// signal until the reloader is listening and the configuration is reloaded
+	m.set(map[string]interface{}{"name": "a", "port": 8080, "labels.stage": "prod"})
+	waitFor(t, func() bool {
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+		return s.Load().Port == 8080
+	})
+	waitFor(t, func() bool { return strings.Contains(logs.String(), "configuration reloaded") })
+	if out := logs.String(); !strings.Contains(out, "changed=\"[Port Labels.stage Labels.team]\"") {
+		t.Errorf("Value is '%s', but changed keys expected", out)
+	}
+
+	m.set(map[string]interface{}{"name": "b", "port": -1})
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return strings.Contains(logs.String(), "configuration reload failed") })
+	if cfg := s.Load(); cfg.Name != "a" || cfg.Port != 8080 {
+		t.Errorf("Value is '%+v', but previous configuration expected", cfg)
+	}
+
+	cancel()
+	<-done
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultSignals trigger the reload if Reloader.Signals are not set.
+var defaultSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds the configuration as an immutable snapshot, which can be read by concurrent goroutines
+// while the configuration is reloaded. Each reload parses the configuration into a new value, starting from
+// a deep copy of the defaults, and replaces the snapshot only if the parsing and validation succeed.
+// Snapshots returned by Load must not be modified.
+type Store[T any] struct {
+	c        *C
+	defaults T
+	current  atomic.Pointer[T]
+	mu       sync.Mutex
+
+	subMu sync.Mutex
+	subID int
+	subs  map[int]func(old, new *T)
+}
+
+// Validator is an optional interface of configuration types. Store rejects configuration, which is not valid.
+type Validator interface {
+
+	// Validate returns an error if the configuration is not valid.
+	Validate() error
+}
+
+// NewStore is a constructor method which creates the store and parses the initial configuration.
+func NewStore[T any](c *C, defaults T) (*Store[T], error) {
+	return NewStoreContext(context.Background(), c, defaults)
+}
+
+// NewStoreContext creates the store like NewStore, parsing the initial configuration with context.
+func NewStoreContext[T any](ctx context.Context, c *C, defaults T) (*Store[T], error) {
+	s := &Store[T]{c: c, defaults: deepCopy(defaults)}
+	if err := s.ReloadContext(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Load returns the current configuration snapshot.
+func (s *Store[T]) Load() *T {
+	return s.current.Load()
+}
+
+// Reload parses the configuration again and replaces the snapshot if the parsing succeeds.
+func (s *Store[T]) Reload() error {
+	return s.ReloadContext(context.Background())
+}
+
+// ReloadContext parses the configuration again with context and replaces the snapshot if the parsing succeeds.
+// Concurrent reloads are serialized.
+func (s *Store[T]) ReloadContext(ctx context.Context) error {
+	_, _, err := s.reload(ctx)
+	return err
+}
+
+// reload parses the configuration and returns previous and new snapshots.
+func (s *Store[T]) reload(ctx context.Context) (*T, *T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cfg := deepCopy(s.defaults)
+	if err := s.c.ParseContext(ctx, &cfg); err != nil {
+		return nil, nil, err
+	}
+	if v, ok := interface{}(&cfg).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+	old := s.current.Swap(&cfg)
+	if old == nil {
+		return nil, &cfg, nil
+	}
+
+	s.subMu.Lock()
+	ids := make([]int, 0, len(s.subs))
+	for id := range s.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	notify := make([]func(old, new *T), len(ids))
+	for i, id := range ids {
+		notify[i] = s.subs[id]
+	}
+	s.subMu.Unlock()
+
+	for _, fn := range notify {
+		fn(old, &cfg)
+	}
+	return old, &cfg, nil
+}
+
+// Subscribe registers fn, which is called after a successful reload of the store, if the value at path changed.
+// Path is a dot-separated list of configuration field names, matched case-insensitively with the same names
+// as environment variables, e.g. "database" or "database.pool". Empty path subscribes to the whole configuration.
+// V must be the type of the field. Subscribers are called synchronously in order of subscription, after the new
+// snapshot is stored, and must not reload the store. The returned function cancels the subscription.
+func Subscribe[T, V any](s *Store[T], path string, fn func(old, new V)) (func(), error) {
+	var segments []string
+	if path != "" {
+		segments = strings.Split(path, ".")
+	}
+
+	t, ok := configFieldType(reflect.TypeOf((*T)(nil)).Elem(), segments)
+	if !ok {
+		return nil, fmt.Errorf("subscribe: field %q not found", path)
+	}
+	if want := reflect.TypeOf((*V)(nil)).Elem(); t != want {
+		return nil, fmt.Errorf("subscribe: field %q is %s, but subscriber expects %s", path, t, want)
+	}
+
+	notify := func(old, new *T) {
+		oldVal := configFieldValue(reflect.ValueOf(old).Elem(), segments, t)
+		newVal := configFieldValue(reflect.ValueOf(new).Elem(), segments, t)
+		if !reflect.DeepEqual(oldVal.Interface(), newVal.Interface()) {
+			fn(oldVal.Interface().(V), newVal.Interface().(V))
+		}
+	}
+
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	if s.subs == nil {
+		s.subs = map[int]func(old, new *T){}
+	}
+	s.subID++
+	id := s.subID
+	s.subs[id] = notify
+
+	return func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		delete(s.subs, id)
+	}, nil
+}
+
+// configFieldType returns the type of the struct field at path of configuration field names.
+func configFieldType(t reflect.Type, path []string) (reflect.Type, bool) {
+	if len(path) == 0 {
+		return t, true
+	}
+	st := t
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	if st.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	for i := 0; i < st.NumField(); i++ {
+		tf := st.Field(i)
+		fieldName, ok := envFieldName(tf)
+		if !ok || !tf.IsExported() {
+			continue
+		}
+		if isInlineField(tf) && tf.Type.Kind() == reflect.Struct {
+			if ft, ok := configFieldType(tf.Type, path); ok {
+				return ft, true
+			}
+			continue
+		}
+		if strings.EqualFold(fieldName, path[0]) || strings.EqualFold(tf.Name, path[0]) {
+			return configFieldType(tf.Type, path[1:])
+		}
+	}
+	return nil, false
+}
+
+// configFieldValue returns the value of the struct field at path, or zero value of t if a pointer on the path is nil.
+func configFieldValue(v reflect.Value, path []string, t reflect.Type) reflect.Value {
+	for _, name := range path {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(t)
+			}
+			v = v.Elem()
+		}
+		f, ok := findConfigField(v, name)
+		if !ok {
+			return reflect.Zero(t)
+		}
+		v = f
+	}
+	return v
+}
+
+// deepCopy returns a copy of v, which does not share pointers, slices and maps with v.
+func deepCopy[T any](v T) T {
+	var out T
+	src := reflect.ValueOf(&v).Elem()
+	dst := reflect.ValueOf(&out).Elem()
+	copyValue(src, dst)
+	return out
+}
+
+func copyValue(src, dst reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		copyValue(src.Elem(), p.Elem())
+		dst.Set(p)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		e := reflect.New(src.Elem().Type()).Elem()
+		copyValue(src.Elem(), e)
+		dst.Set(e)
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			copyValue(src.Index(i), s.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			copyValue(src.Index(i), dst.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			e := reflect.New(src.Type().Elem()).Elem()
+			copyValue(iter.Value(), e)
+			m.SetMapIndex(iter.Key(), e)
+		}
+		dst.Set(m)
+	case reflect.Struct:
+		// unexported fields can not be set using reflection, they are copied with the struct value
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				copyValue(src.Field(i), dst.Field(i))
+			}
+		}
+	default:
+		dst.Set(src)
+	}
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type storeCfg struct {
+	Name   string
+	Labels map[string]string
+	Hosts  []string
+	TLS    *struct {
+		Cert string
+	}
+}
+
+type pFailing struct {
+	err error
+}
+
+func (p *pFailing) Provide(config interface{}) error {
+	return p.err
+}
+
+func TestStore(t *testing.T) {
+	defaults := storeCfg{Name: "default", Labels: map[string]string{"team": "core"}}
+	m := &Map{Values: map[string]interface{}{"labels.stage": "dev", "tls.cert": "a.pem"}}
+	failing := &pFailing{}
+	c := New()
+	c.WithProviders(m, failing)
+
+	s, err := NewStore(c, defaults)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	first := s.Load()
+	if first.Name != "default" || first.Labels["stage"] != "dev" || first.TLS.Cert != "a.pem" {
+		t.Errorf("Value is '%+v', but configured values expected", first)
+	}
+
+	m.Values = map[string]interface{}{"labels.stage": "prod", "tls.cert": "b.pem"}
+	if err := s.Reload(); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	second := s.Load()
+	if second.Labels["stage"] != "prod" || second.TLS.Cert != "b.pem" {
+		t.Errorf("Value is '%+v', but reloaded values expected", second)
+	}
+	// previous snapshot and defaults are not modified by reload
+	if first.Labels["stage"] != "dev" || first.TLS.Cert != "a.pem" {
+		t.Errorf("Value is '%+v', but previous snapshot expected", first)
+	}
+	if len(defaults.Labels) != 1 {
+		t.Errorf("Value is '%v', but unmodified defaults expected", defaults.Labels)
+	}
+
+	failing.err = errors.New("invalid configuration")
+	m.Values = map[string]interface{}{"labels.stage": "broken"}
+	if err := s.Reload(); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	if s.Load() != second {
+		t.Errorf("Value is '%+v', but previous snapshot expected", s.Load())
+	}
+}
+
+func TestStoreInitialError(t *testing.T) {
+	c := New()
+	c.WithProviders(&pFailing{err: errors.New("invalid configuration")})
+	if _, err := NewStore(c, storeCfg{}); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+}
+
+func TestStoreConcurrentReaders(t *testing.T) {
+	c := New()
+	c.WithProviders(&Map{Values: map[string]interface{}{"name": "value", "hosts": []string{"a", "b"}}})
+	s, err := NewStore(c, storeCfg{Labels: map[string]string{"team": "core"}})
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cfg := s.Load()
+				if cfg.Name != "value" || len(cfg.Hosts) != 2 || cfg.Labels["team"] != "core" {
+					t.Errorf("Value is '%+v', but consistent snapshot expected", cfg)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		if err := s.Reload(); err != nil {
+			t.Errorf("No error expected, but was: %v\n", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestDeepCopy(t *testing.T) {
+	type nested struct {
+		Values []int
+	}
+	src := struct {
+		Ptr   *nested
+		Map   map[string]*nested
+		Array [2][]string
+		Any   interface{}
+	}{
+		Ptr:   &nested{Values: []int{1}},
+		Map:   map[string]*nested{"a": {Values: []int{2}}},
+		Array: [2][]string{{"x"}, nil},
+		Any:   []string{"y"},
+	}
+
+	dst := deepCopy(src)
+	dst.Ptr.Values[0] = 10
+	dst.Map["a"].Values[0] = 20
+	dst.Array[0][0] = "changed"
+	dst.Any.([]string)[0] = "changed"
+
+	if src.Ptr.Values[0] != 1 || src.Map["a"].Values[0] != 2 || src.Array[0][0] != "x" || src.Any.([]string)[0] != "y" {
+		t.Errorf("Value is '%+v', but unmodified source expected", src)
+	}
+}
+
+func TestStoreSubscribe(t *testing.T) {
+	type database struct {
+		Host string
+		Pool int
+	}
+	type cfg struct {
+		Database database
+		Logging  *Logging `yaml:"log"`
+	}
+
+	m := &Map{Values: map[string]interface{}{"database.host": "a", "log.level": "info"}}
+	c := New()
+	c.WithProviders(m)
+	s, err := NewStore(c, cfg{})
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	var dbChanges []string
+	cancel, err := Subscribe(s, "database", func(old, new database) {
+		dbChanges = append(dbChanges, old.Host+"->"+new.Host)
+	})
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	var levels []string
+	if _, err := Subscribe(s, "LOG.level", func(old, new string) {
+		levels = append(levels, old+"->"+new)
+	}); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	// only log level changed
+	m.Values = map[string]interface{}{"database.host": "a", "log.level": "debug"}
+	if err := s.Reload(); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	// database changed and logging removed
+	m.Values = map[string]interface{}{"database.host": "b"}
+	if err := s.Reload(); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	cancel()
+	m.Values = map[string]interface{}{"database.host": "c"}
+	if err := s.Reload(); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if !reflect.DeepEqual(dbChanges, []string{"a->b"}) {
+		t.Errorf("Value is '%v', but %v expected", dbChanges, []string{"a->b"})
+	}
+	if !reflect.DeepEqual(levels, []string{"info->debug", "debug->"}) {
+		t.Errorf("Value is '%v', but %v expected", levels, []string{"info->debug", "debug->"})
+	}
+}
+
+func TestSubscribeInvalidPath(t *testing.T) {
+	c := New()
+	s, err := NewStore(c, storeCfg{})
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if _, err := Subscribe(s, "missing", func(old, new string) {}); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	if _, err := Subscribe(s, "name", func(old, new int) {}); err == nil {
+		t.Fatalf("Error expected, but there is none.")
+	}
+	if _, err := Subscribe(s, "tls.cert", func(old, new string) {}); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+}
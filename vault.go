@@ -0,0 +1,337 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Vault is a provider for configuration using secrets from KV v2 secrets engine of a Vault-compatible API.
+// Only fields tagged with the secret path and the key within the secret are configured,
+// e.g. `vault:"secret/data/db#password"`. Values are converted to the type of the field.
+// Token or AppRole authentication can be used.
+type Vault struct {
+	// Address of the Vault API, VAULT_ADDR or "https://127.0.0.1:8200" is used if not set
+	Address string
+	// Token used for authentication, VAULT_TOKEN is used if neither Token nor AppRole credentials are set
+	Token string
+	// RoleID and SecretID used for AppRole authentication
+	RoleID   string
+	SecretID string
+	// AppRoleMount is the mount path of the AppRole auth method, "approle" is used if not set
+	AppRoleMount string
+	// Namespace sent in the X-Vault-Namespace header, if set
+	Namespace string
+	// TLSConfig is used for https requests, ignored if Client is set
+	TLSConfig *tls.Config
+	// Client used for requests, a client with TLSConfig is created if not set
+	Client *http.Client
+	// RefreshInterval of Watch for secrets without lease, five minutes is used if not set
+	RefreshInterval time.Duration
+
+	mu           sync.Mutex
+	client       *http.Client
+	token        string
+	tokenExpires time.Time
+	secrets      map[string]string
+	lease        time.Duration
+}
+
+type vaultSecret struct {
+	LeaseDuration int `json:"lease_duration"`
+	Data          struct {
+		Data     map[string]interface{} `json:"data"`
+		Metadata struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+// Provide loads secrets from Vault
+func (v *Vault) Provide(config interface{}) error {
+	return v.ProvideContext(context.Background(), config)
+}
+
+// ProvideContext loads secrets from Vault, requests are canceled when ctx is done
+func (v *Vault) ProvideContext(ctx context.Context, config interface{}) error {
+	secrets := map[string]*vaultSecret{}
+	err := v.assignSecrets(ctx, "", reflect.ValueOf(config).Elem(), secrets, map[reflect.Type]bool{})
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.secrets = make(map[string]string, len(secrets))
+	v.lease = 0
+	for path, secret := range secrets {
+		v.secrets[path] = secretFingerprint(secret)
+		lease := time.Duration(secret.LeaseDuration) * time.Second
+		if lease > 0 && (v.lease == 0 || lease < v.lease) {
+			v.lease = lease
+		}
+	}
+	return nil
+}
+
+// Watch periodically reads secrets configured by the last call of Provide and notifies when any of them changes.
+// Secrets are read again after half of the shortest lease duration, or after RefreshInterval if they have no lease.
+func (v *Vault) Watch(ctx context.Context) (<-chan struct{}, error) {
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(v.refreshInterval()):
+			}
+
+			if v.refresh(ctx) {
+				select {
+				case ch <- struct{}{}:
+				default:
+					// notification is already pending
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+func (v *Vault) refreshInterval() time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.lease > 0 {
+		return v.lease / 2
+	}
+	if v.RefreshInterval > 0 {
+		return v.RefreshInterval
+	}
+	return 5 * time.Minute
+}
+
+// refresh reads known secrets and reports whether any of them changed. Failed reads are ignored.
+func (v *Vault) refresh(ctx context.Context) bool {
+	v.mu.Lock()
+	known := make(map[string]string, len(v.secrets))
+	for path, fp := range v.secrets {
+		known[path] = fp
+	}
+	v.mu.Unlock()
+
+	changed := false
+	for path, fp := range known {
+		secret, err := v.read(ctx, path)
+		if err != nil {
+			continue
+		}
+		if next := secretFingerprint(secret); next != fp {
+			changed = true
+			v.mu.Lock()
+			v.secrets[path] = next
+			v.mu.Unlock()
+		}
+	}
+	return changed
+}
+
+func secretFingerprint(secret *vaultSecret) string {
+	b, _ := json.Marshal(secret.Data)
+	return string(b)
+}
+
+// assignSecrets assigns secrets to tagged fields of the struct v, reading each secret only once.
+// Nil pointers to struct types already being assigned are skipped, so recursive types terminate.
+func (v *Vault) assignSecrets(ctx context.Context, path string, val reflect.Value, secrets map[string]*vaultSecret,
+	visiting map[reflect.Type]bool) error {
+	t := val.Type()
+	visiting[t] = true
+	defer delete(visiting, t)
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		vf := val.Field(i)
+		fieldName, ok := envFieldName(tf)
+		if !ok {
+			continue
+		}
+		fieldPath := joinPath(path, fieldName)
+		if isInlineField(tf) {
+			fieldPath = path
+		}
+
+		tag := tf.Tag.Get("vault")
+		if tag == "" {
+			var err error
+			switch {
+			case vf.Kind() == reflect.Struct:
+				err = v.assignSecrets(ctx, fieldPath, vf, secrets, visiting)
+			case isStructPointer(vf.Type()) && !(vf.IsNil() && visiting[vf.Type().Elem()]):
+				err = withStructPointer(vf, func(p reflect.Value) error {
+					return v.assignSecrets(ctx, fieldPath, p.Elem(), secrets, visiting)
+				})
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		secretPath, key, ok := strings.Cut(tag, "#")
+		if !ok || secretPath == "" || key == "" {
+			return fmt.Errorf("vault: invalid tag %q of field %s, \"path#key\" expected", tag, fieldPath)
+		}
+		secret, ok := secrets[secretPath]
+		if !ok {
+			var err error
+			secret, err = v.read(ctx, secretPath)
+			if err != nil {
+				return err
+			}
+			secrets[secretPath] = secret
+		}
+
+		value, ok := secret.Data.Data[key]
+		if !ok {
+			return fmt.Errorf("vault: key %q not found in secret %q", key, secretPath)
+		}
+		if err := assignValue(fieldPath, value, vf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// read reads the secret at path.
+func (v *Vault) read(ctx context.Context, path string) (*vaultSecret, error) {
+	token, err := v.authToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var secret vaultSecret
+	err = v.do(ctx, http.MethodGet, "/v1/"+escapeKeyPath(strings.Trim(path, "/")), nil, token, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("vault: reading secret %q: %w", path, err)
+	}
+	return &secret, nil
+}
+
+// authToken returns the configured token, or logs in using AppRole if the previous token expired.
+func (v *Vault) authToken(ctx context.Context) (string, error) {
+	if v.Token != "" {
+		return v.Token, nil
+	}
+	if v.RoleID == "" {
+		if token := os.Getenv("VAULT_TOKEN"); token != "" {
+			return token, nil
+		}
+		return "", errors.New("vault: no token or AppRole credentials")
+	}
+
+	v.mu.Lock()
+	token, expires := v.token, v.tokenExpires
+	v.mu.Unlock()
+	if token != "" && (expires.IsZero() || time.Now().Before(expires)) {
+		return token, nil
+	}
+
+	mount := v.AppRoleMount
+	if mount == "" {
+		mount = "approle"
+	}
+	var login struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}
+	creds := map[string]string{"role_id": v.RoleID, "secret_id": v.SecretID}
+	err := v.do(ctx, http.MethodPost, "/v1/auth/"+strings.Trim(mount, "/")+"/login", creds, "", &login)
+	if err != nil {
+		return "", fmt.Errorf("vault: AppRole login: %w", err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.token = login.Auth.ClientToken
+	v.tokenExpires = time.Time{}
+	if login.Auth.LeaseDuration > 0 {
+		// renew the token before it expires
+		ttl := time.Duration(login.Auth.LeaseDuration) * time.Second
+		v.tokenExpires = time.Now().Add(ttl * 9 / 10)
+	}
+	return v.token, nil
+}
+
+func (v *Vault) do(ctx context.Context, method, path string, body interface{}, token string, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(v.address(), "/")+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := v.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (v *Vault) address() string {
+	if v.Address != "" {
+		return v.Address
+	}
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+		return addr
+	}
+	return "https://127.0.0.1:8200"
+}
+
+func (v *Vault) httpClient() *http.Client {
+	if v.Client != nil {
+		return v.Client
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = v.TLSConfig
+		v.client = &http.Client{Transport: transport}
+	}
+	return v.client
+}
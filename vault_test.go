@@ -0,0 +1,212 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVault implements the subset of the Vault HTTP API used by the Vault provider.
+type fakeVault struct {
+	mu      sync.Mutex
+	secrets map[string]map[string]interface{}
+	logins  int
+}
+
+func (f *fakeVault) set(path, key string, value interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.secrets[path][key] = value
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var creds map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil ||
+			creds["role_id"] != "role" || creds["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.logins++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "approle-token", "lease_duration": 3600},
+		})
+		return
+	}
+
+	if token := r.Header.Get("X-Vault-Token"); token != "token" && token != "approle-token" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	data, ok := f.secrets[strings.TrimPrefix(r.URL.Path, "/v1/")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{"data": data, "metadata": map[string]interface{}{"version": 1}},
+	})
+}
+
+func newFakeVault() *fakeVault {
+	return &fakeVault{secrets: map[string]map[string]interface{}{
+		"secret/data/db":  {"password": "s3cr3t", "port": "5432"},
+		"secret/data/api": {"key": "api-key"},
+	}}
+}
+
+type vaultCfg struct {
+	Database struct {
+		Host     string
+		Password string `vault:"secret/data/db#password"`
+		Port     int    `vault:"secret/data/db#port"`
+	}
+	API *struct {
+		Key string `vault:"secret/data/api#key"`
+	}
+}
+
+func TestVault_Provide(t *testing.T) {
+	srv := httptest.NewServer(newFakeVault())
+	defer srv.Close()
+
+	cfg := vaultCfg{}
+	cfg.Database.Host = "localhost"
+	v := Vault{Address: srv.URL, Token: "token"}
+	if err := v.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.Database.Host != "localhost" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Database.Host, "localhost")
+	}
+	if cfg.Database.Password != "s3cr3t" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Database.Password, "s3cr3t")
+	}
+	if cfg.Database.Port != 5432 {
+		t.Errorf("Value is '%d', but %d expected", cfg.Database.Port, 5432)
+	}
+	if cfg.API == nil || cfg.API.Key != "api-key" {
+		t.Errorf("Value is '%v', but %q expected", cfg.API, "api-key")
+	}
+}
+
+func TestVaultRecursiveType(t *testing.T) {
+	srv := httptest.NewServer(newFakeVault())
+	defer srv.Close()
+
+	type node struct {
+		Password string `vault:"secret/data/db#password"`
+		Next     *node
+	}
+	cfg := node{Next: &node{}}
+	v := Vault{Address: srv.URL, Token: "token"}
+	if err := v.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.Password != "s3cr3t" || cfg.Next.Password != "s3cr3t" {
+		t.Errorf("Value is '%+v', but %q expected", cfg, "s3cr3t")
+	}
+	if cfg.Next.Next != nil {
+		t.Errorf("Value is '%+v', but nil expected", cfg.Next.Next)
+	}
+}
+
+func TestVaultAppRole(t *testing.T) {
+	fake := newFakeVault()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	v := Vault{Address: srv.URL, RoleID: "role", SecretID: "secret"}
+	for i := 0; i < 2; i++ {
+		cfg := vaultCfg{}
+		if err := v.Provide(&cfg); err != nil {
+			t.Fatalf("No error expected, but was: %v\n", err)
+		}
+		if cfg.Database.Password != "s3cr3t" {
+			t.Errorf("Value is '%s', but %q expected", cfg.Database.Password, "s3cr3t")
+		}
+	}
+	if fake.logins != 1 {
+		t.Errorf("Value is '%d', but %d expected", fake.logins, 1)
+	}
+}
+
+func TestVaultErrors(t *testing.T) {
+	srv := httptest.NewServer(newFakeVault())
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		vault *Vault
+		cfg   interface{}
+	}{
+		{"unauthorized", &Vault{Address: srv.URL, Token: "invalid"}, &vaultCfg{}},
+		{"invalid AppRole", &Vault{Address: srv.URL, RoleID: "role", SecretID: "invalid"}, &vaultCfg{}},
+		{"missing secret", &Vault{Address: srv.URL, Token: "token"}, &struct {
+			Password string `vault:"secret/data/missing#password"`
+		}{}},
+		{"missing key", &Vault{Address: srv.URL, Token: "token"}, &struct {
+			Password string `vault:"secret/data/db#missing"`
+		}{}},
+		{"invalid tag", &Vault{Address: srv.URL, Token: "token"}, &struct {
+			Password string `vault:"secret/data/db"`
+		}{}},
+		{"invalid value", &Vault{Address: srv.URL, Token: "token"}, &struct {
+			Port int `vault:"secret/data/db#password"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.vault.Provide(tt.cfg); err == nil {
+				t.Fatalf("Error expected, but there is none.")
+			}
+		})
+	}
+}
+
+func TestVaultWatch(t *testing.T) {
+	fake := newFakeVault()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	v := Vault{Address: srv.URL, Token: "token", RefreshInterval: 10 * time.Millisecond}
+	if err := v.Provide(&vaultCfg{}); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := v.Watch(ctx)
+	if err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	fake.set("secret/data/db", "password", "rotated")
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Change notification expected")
+	}
+
+	cfg := vaultCfg{}
+	if err := v.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.Database.Password != "rotated" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Database.Password, "rotated")
+	}
+
+	cancel()
+	for range changes {
+	}
+}
@@ -1,44 +1,561 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
 )
 
 // Yaml is a provider for configuration using yaml file
 type Yaml struct {
+	// Path of the yaml file. If a relative path is provided, it is looked up in SearchPaths, or resolved
+	// relative to the application's executable directory if SearchPaths are not set, or to the root of FS.
+	// "~" and environment variables are expanded. "config.yaml" is used if not set.
 	Path string
+	// FS is the file system the file is read from, e.g. embed.FS, the operating system is used if not set
+	FS fs.FS
+	// SearchPaths are directories searched in order for relative Path, the first existing file is used.
+	// "~" and environment variables are expanded, see DefaultSearchPaths.
+	SearchPaths []string
+	// Flag is the name of a command-line flag overriding Path, e.g. "config" for --config, ignored if not set
+	Flag string
+	// EnvVar is the name of an environment variable overriding Path, e.g. "APP_CONFIG", ignored if not set.
+	// Flag takes precedence over EnvVar. Relative paths of both are resolved against the working directory.
+	EnvVar string
+	// Profiles are active profiles selecting documents by their "profile" or "when" key
+	Profiles []string
+	// KeyFile is the path of the file with base64 encoded key used to decrypt encrypted values
+	KeyFile string
+	// KeyEnv is the name of the environment variable with base64 encoded key, used if KeyFile is not set
+	KeyEnv string
+	// Strict reports keys, which do not match any configuration field, as errors
+	Strict bool
+
+	mu   sync.Mutex
+	file string
 }
 
 // Provide loads configuration from yaml file
 func (y *Yaml) Provide(config interface{}) error {
-	b, err := y.readFile()
+	_, err := y.ProvideUnset(config)
+	return err
+}
+
+// ProvideUnset loads configuration from yaml file and returns paths of values explicitly set to null
+func (y *Yaml) ProvideUnset(config interface{}) ([][]string, error) {
+	b, file, err := y.readFile()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	y.mu.Lock()
+	y.file = file
+	y.mu.Unlock()
+
+	if hasEncryptedValues(b) {
+		key, err := LoadKey(y.KeyFile, y.KeyEnv)
+		if err != nil {
+			return nil, fmt.Errorf("%s: decrypting values: %w", file, err)
+		}
+		if b, err = decryptYaml(b, key); err != nil {
+			return nil, fmt.Errorf("%s: decrypting values: %w", file, err)
+		}
 	}
 
-	err = yaml.Unmarshal(b, config)
+	return decodeYaml(b, config, y.Profiles, y.Strict)
+}
+
+// decodeYaml decodes yaml stream into config and returns paths of values explicitly set to null.
+// Documents of multi-document streams are layered in order, the same as separate providers. Documents
+// with a top-level "profile" or "when" selector key are applied only if any of their profiles is active.
+// Keys, which do not match any configuration field, are reported as errors if strict is set.
+func decodeYaml(b []byte, config interface{}, profiles []string, strict bool) ([][]string, error) {
+	f, err := parser.ParseBytes(b, 0)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	var docs []*ast.DocumentNode
+	for _, doc := range f.Docs {
+		if doc.Body != nil {
+			docs = append(docs, doc)
+		}
+	}
+	target := reflect.ValueOf(config)
+	if len(docs) <= 1 {
+		if len(docs) == 1 {
+			active, selected, err := selectDocument(docs[0], profiles, target.Type().Elem())
+			if err != nil {
+				return nil, err
+			}
+			if !active {
+				return nil, nil
+			}
+			if selected {
+				b = []byte(docs[0].Body.String())
+			}
+			if strict {
+				if err := unknownKey(docs[0].Body, target.Type(), nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return decodeDocument(b, docs, config)
 	}
 
-	return nil
+	var unset [][]string
+	for _, doc := range docs {
+		active, _, err := selectDocument(doc, profiles, target.Type().Elem())
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			continue
+		}
+		if strict {
+			if err := unknownKey(doc.Body, target.Type(), nil); err != nil {
+				return nil, err
+			}
+		}
+
+		source := reflect.New(target.Type().Elem())
+		docUnset, err := decodeDocument([]byte(doc.Body.String()), []*ast.DocumentNode{doc}, source.Interface())
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range docUnset {
+			unsetPath(target.Elem(), path)
+		}
+		mergeConfig(source, target)
+		unset = append(unset, docUnset...)
+	}
+	return unset, nil
 }
 
-func (y *Yaml) readFile() ([]byte, error) {
-	p, err := y.resolvePath()
+// decodeDocument decodes single yaml document into config and returns paths of values explicitly set to null.
+func decodeDocument(b []byte, docs []*ast.DocumentNode, config interface{}) ([][]string, error) {
+	err := yaml.Unmarshal(b, config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = decodeEmbedded(b, nil, reflect.ValueOf(config).Elem())
 	if err != nil {
 		return nil, err
 	}
 
-	return os.ReadFile(p)
+	var unset [][]string
+	for _, doc := range docs {
+		nullPaths(doc.Body, nil, &unset)
+	}
+	// null map entries are decoded as zero values, which must not be merged over values of other sources
+	for _, path := range unset {
+		unsetPath(reflect.ValueOf(config).Elem(), path)
+	}
+	return unset, nil
+}
+
+// selectDocument reports whether the document is active for profiles and whether a selector key was removed
+// from it. Documents without "profile" or "when" key are always active. The keys are selectors only if the
+// configuration type t has no field with that name, otherwise they are decoded as values.
+func selectDocument(doc *ast.DocumentNode, profiles []string, t reflect.Type) (bool, bool, error) {
+	m, ok := doc.Body.(*ast.MappingNode)
+	if !ok {
+		return true, false, nil
+	}
+
+	active := true
+	values := m.Values[:0:0]
+	for _, v := range m.Values {
+		key := v.Key.GetToken().Value
+		if key != "profile" && key != "when" || isYamlField(t, key) {
+			values = append(values, v)
+			continue
+		}
+
+		var selector interface{}
+		if err := yaml.NodeToValue(v.Value, &selector); err != nil {
+			return false, false, err
+		}
+		var names []string
+		switch sel := selector.(type) {
+		case string:
+			names = strings.Split(sel, ",")
+		case []interface{}:
+			for _, name := range sel {
+				names = append(names, fmt.Sprint(name))
+			}
+		default:
+			return false, false, fmt.Errorf("%s: profile name or list of profile names expected", key)
+		}
+		active = active && containsProfile(profiles, names)
+	}
+	selected := len(values) != len(m.Values)
+	m.Values = values
+	return active, selected, nil
+}
+
+// isYamlField reports whether struct type t has a field decoded from the yaml key.
+func isYamlField(t reflect.Type, key string) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	_, ok := yamlFieldType(t, key)
+	return ok
+}
+
+func containsProfile(profiles, names []string) bool {
+	for _, name := range names {
+		for _, p := range profiles {
+			if strings.TrimSpace(name) == p {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nullPaths collects paths of null values (null, ~ or empty value) in yaml node.
+func nullPaths(node ast.Node, path []string, paths *[][]string) {
+	switch n := node.(type) {
+	case *ast.NullNode:
+		if len(path) > 0 {
+			*paths = append(*paths, path)
+		}
+	case *ast.MappingNode:
+		for _, v := range n.Values {
+			nullPaths(v, path, paths)
+		}
+	case *ast.MappingValueNode:
+		if _, ok := n.Key.(*ast.MergeKeyNode); ok {
+			return
+		}
+		key := n.Key.GetToken().Value
+		nullPaths(n.Value, append(path[:len(path):len(path)], key), paths)
+	case *ast.SequenceNode:
+		for i, v := range n.Values {
+			nullPaths(v, append(path[:len(path):len(path)], strconv.Itoa(i)), paths)
+		}
+	case *ast.TagNode:
+		nullPaths(n.Value, path, paths)
+	case *ast.AnchorNode:
+		nullPaths(n.Value, path, paths)
+	}
+}
+
+// unknownKey returns an error for the first mapping key in yaml node, which does not match any field of
+// the configuration type t. Keys are matched the same way as by goccy/go-yaml, embedded structs are flattened.
+func unknownKey(node ast.Node, t reflect.Type, path []string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, v := range n.Values {
+			if err := unknownKey(v, t, path); err != nil {
+				return err
+			}
+		}
+	case *ast.MappingValueNode:
+		if _, ok := n.Key.(*ast.MergeKeyNode); ok {
+			return nil
+		}
+		key := n.Key.GetToken().Value
+		next := append(path[:len(path):len(path)], key)
+		switch t.Kind() {
+		case reflect.Struct:
+			ft, ok := yamlFieldType(t, key)
+			if !ok {
+				return fmt.Errorf("line %d: unknown field %q", n.Key.GetToken().Position.Line, strings.Join(next, "."))
+			}
+			return unknownKey(n.Value, ft, next)
+		case reflect.Map:
+			return unknownKey(n.Value, t.Elem(), next)
+		}
+	case *ast.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		for i, v := range n.Values {
+			if err := unknownKey(v, t.Elem(), append(path[:len(path):len(path)], strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	case *ast.TagNode:
+		return unknownKey(n.Value, t, path)
+	case *ast.AnchorNode:
+		return unknownKey(n.Value, t, path)
+	}
+	return nil
+}
+
+// yamlFieldType returns the type of the struct field decoded from the yaml key.
+func yamlFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		name := strings.Split(tf.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if isInlineField(tf) {
+			ft := tf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft, ok := yamlFieldType(ft, key); ok {
+				return ft, true
+			}
+			continue
+		}
+		if !tf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(tf.Name)
+		}
+		if name == key {
+			return tf.Type, true
+		}
+	}
+	return nil, false
+}
+
+// decodeEmbedded decodes yaml document into anonymous embedded structs without a yaml tag.
+// goccy/go-yaml nests them under the type name, while other providers flatten them into the parent.
+func decodeEmbedded(b []byte, path []string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tf := t.Field(i)
+		vf := v.Field(i)
+		if vf.Kind() != reflect.Struct && !isStructPointer(vf.Type()) {
+			continue
+		}
+		tag := tf.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		if tf.Anonymous && tag == "" {
+			embedded := reflect.New(tf.Type)
+			if vf.Kind() == reflect.Ptr {
+				embedded = reflect.New(tf.Type.Elem())
+			}
+			err := readPath(b, path, embedded.Interface())
+			if err != nil {
+				return err
+			}
+			err = decodeEmbedded(b, path, embedded.Elem())
+			if err != nil {
+				return err
+			}
+			if vf.Kind() == reflect.Ptr {
+				if !embedded.Elem().IsZero() {
+					mergePointer(embedded, vf)
+				}
+			} else {
+				mergeStructValue(embedded.Elem(), vf)
+			}
+			continue
+		}
+
+		if vf.Kind() == reflect.Ptr {
+			// goccy/go-yaml allocates pointers only for values present in the document
+			if vf.IsNil() {
+				continue
+			}
+			vf = vf.Elem()
+		}
+		next := path
+		if !isInlineField(tf) {
+			name := strings.Split(tag, ",")[0]
+			if name == "" {
+				name = strings.ToLower(tf.Name)
+			}
+			next = append(path[:len(path):len(path)], name)
+		}
+		if err := decodeEmbedded(b, next, vf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readPath(b []byte, path []string, v interface{}) error {
+	if len(path) == 0 {
+		return yaml.Unmarshal(b, v)
+	}
+
+	builder := (&yaml.PathBuilder{}).Root()
+	for _, p := range path {
+		builder = builder.Child(p)
+	}
+	err := builder.Build().Read(bytes.NewReader(b), v)
+	if yaml.IsNotFoundNodeError(err) {
+		return nil
+	}
+	return err
+}
+
+// File returns the path of the file read by the last call of Provide
+func (y *Yaml) File() string {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+	return y.file
+}
+
+// DefaultSearchPaths returns the conventional search paths of configuration files of the application:
+// the current working directory, $XDG_CONFIG_HOME/<app> (~/.config/<app> if XDG_CONFIG_HOME is not set),
+// /etc/<app> and the executable directory.
+func DefaultSearchPaths(app string) []string {
+	paths := []string{"."}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		paths = append(paths, filepath.Join(xdg, app))
+	} else {
+		paths = append(paths, filepath.Join("~", ".config", app))
+	}
+	paths = append(paths, filepath.Join("/etc", app))
+	if dir, err := execDir(); err == nil {
+		paths = append(paths, dir)
+	}
+	return paths
+}
+
+// readFile reads the configuration file and returns its content and path.
+func (y *Yaml) readFile() ([]byte, string, error) {
+	path := y.Path
+	override := false
+	if v, ok := flagValue(os.Args[1:], y.Flag); ok {
+		path, override = v, true
+	} else if v := os.Getenv(y.EnvVar); y.EnvVar != "" && v != "" {
+		path, override = v, true
+	}
+
+	if y.FS == nil {
+		var err error
+		if path, err = expandPath(path); err != nil {
+			return nil, "", err
+		}
+		if override && !filepath.IsAbs(path) {
+			// paths given on the command line or in environment are relative to the working directory
+			if path, err = filepath.Abs(path); err != nil {
+				return nil, "", err
+			}
+		} else if !override && len(y.SearchPaths) > 0 && !filepath.IsAbs(path) {
+			if path, err = searchFile(y.SearchPaths, path, "config.yaml"); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	b, p, err := readFile(y.FS, path, "config.yaml")
+	if override && errors.Is(err, fs.ErrNotExist) {
+		// explicitly requested file is required, even if the provider is optional
+		return nil, "", fmt.Errorf("configuration file set by command-line flag or environment variable: %v", err)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return b, p, nil
+}
+
+// searchFile returns the first existing file with relative path in dirs, using defaultName if path is empty.
+func searchFile(dirs []string, path, defaultName string) (string, error) {
+	if path == "" {
+		path = defaultName
+	}
+
+	var searched []string
+	for _, dir := range dirs {
+		dir, err := expandPath(dir)
+		if err != nil {
+			return "", err
+		}
+		p, err := filepath.Abs(filepath.Join(dir, path))
+		if err != nil {
+			return "", err
+		}
+		if info, err := os.Stat(p); err == nil && !info.IsDir() {
+			return p, nil
+		}
+		searched = append(searched, p)
+	}
+
+	return "", fmt.Errorf("%s not found in %s: %w", path, strings.Join(searched, ", "), fs.ErrNotExist)
+}
+
+// expandPath expands environment variables and leading "~" to the home directory of the user.
+func expandPath(path string) (string, error) {
+	path = os.ExpandEnv(path)
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// flagValue returns the value of the flag with name in args, in forms "-name value", "--name value",
+// "-name=value" and "--name=value". Parsing stops at the "--" terminator.
+func flagValue(args []string, name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		trimmed := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if trimmed == arg {
+			continue
+		}
+		if trimmed == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if v, ok := strings.CutPrefix(trimmed, name+"="); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// readFile reads the file at path from fsys and returns its content and the path used. If fsys is nil,
+// the file is read from the operating system with the path resolved by resolvePath.
+// defaultName is used if path is empty.
+func readFile(fsys fs.FS, path, defaultName string) ([]byte, string, error) {
+	if fsys != nil {
+		if path == "" {
+			path = defaultName
+		}
+		b, err := fs.ReadFile(fsys, path)
+		return b, path, err
+	}
+
+	p, err := resolvePath(path, defaultName)
+	if err != nil {
+		return nil, "", err
+	}
+	b, err := os.ReadFile(p)
+	return b, p, err
 }
 
-func (y *Yaml) resolvePath() (string, error) {
-	if filepath.IsAbs(y.Path) {
-		return y.Path, nil
+// resolvePath resolves relative path against the executable directory, using defaultName if path is empty.
+func resolvePath(path, defaultName string) (string, error) {
+	if filepath.IsAbs(path) {
+		return path, nil
 	}
 
 	dir, err := execDir()
@@ -46,11 +563,11 @@ func (y *Yaml) resolvePath() (string, error) {
 		return "", err
 	}
 
-	if y.Path != "" {
-		return filepath.Join(dir, y.Path), nil
+	if path != "" {
+		return filepath.Join(dir, path), nil
 	}
 
-	return filepath.Join(dir, "config.yaml"), nil
+	return filepath.Join(dir, defaultName), nil
 }
 
 func execDir() (string, error) {
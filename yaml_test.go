@@ -1,9 +1,14 @@
 package config
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestYaml_Provide(t *testing.T) {
@@ -96,3 +101,414 @@ func TestYaml_Provide(t *testing.T) {
 		})
 	}
 }
+
+func TestYamlEmbeddedStructs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "embedded.yaml")
+	content := "service:\n  level: debug\n  format: json\n  name: api\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg struct {
+		Service struct {
+			Logging
+			Output inlineOutput `yaml:",inline"`
+			Name   string
+		}
+	}
+
+	y := Yaml{Path: path}
+	if err := y.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+
+	if cfg.Service.Level != "debug" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Service.Level, "debug")
+	}
+	if cfg.Service.Output.Format != "json" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Service.Output.Format, "json")
+	}
+	if cfg.Service.Name != "api" {
+		t.Errorf("Value is '%s', but %q expected", cfg.Service.Name, "api")
+	}
+}
+
+func TestYamlFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config.yaml":       {Data: []byte("stringfield: default")},
+		"conf/custom.yaml":  {Data: []byte("stringfield: custom")},
+		"conf/invalid.yaml": {Data: []byte("intfield: text")},
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		expectedVal string
+		expectErr   bool
+	}{
+		{name: "Default path", expectedVal: "default"},
+		{name: "Custom path", path: "conf/custom.yaml", expectedVal: "custom"},
+		{name: "Missing file", path: "missing.yaml", expectErr: true},
+		{name: "Invalid file", path: "conf/invalid.yaml", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testCfg{}
+			y := Yaml{Path: tt.path, FS: fsys}
+			err := y.Provide(&cfg)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("Error expected, but there is none.")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+			if cfg.StringField != tt.expectedVal {
+				t.Errorf("Value is '%s', but %q expected", cfg.StringField, tt.expectedVal)
+			}
+		})
+	}
+}
+
+func TestYamlSearchPaths(t *testing.T) {
+	home, first, second := t.TempDir(), t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("YAML_TEST_DIR", second)
+
+	write := func(dir, name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	write(first, "other.yaml", "stringfield: other")
+	secondFile := write(second, "config.yaml", "stringfield: second")
+	homeFile := write(home, "home.yaml", "stringfield: home")
+	overrideFile := write(first, "override.yaml", "stringfield: override")
+
+	tests := []struct {
+		name         string
+		yaml         *Yaml
+		env          string
+		expectedVal  string
+		expectedFile string
+		expectErr    bool
+	}{
+		{
+			name:         "First existing file",
+			yaml:         &Yaml{SearchPaths: []string{first, "$YAML_TEST_DIR"}},
+			expectedVal:  "second",
+			expectedFile: secondFile,
+		},
+		{
+			name:         "Home directory",
+			yaml:         &Yaml{Path: "~/home.yaml", SearchPaths: []string{first}},
+			expectedVal:  "home",
+			expectedFile: homeFile,
+		},
+		{
+			name:         "Env override",
+			yaml:         &Yaml{SearchPaths: []string{second}, EnvVar: "YAML_TEST_CONFIG"},
+			env:          overrideFile,
+			expectedVal:  "override",
+			expectedFile: overrideFile,
+		},
+		{
+			name:      "Not found",
+			yaml:      &Yaml{Path: "missing.yaml", SearchPaths: []string{first, second}},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("YAML_TEST_CONFIG", tt.env)
+			cfg := testCfg{}
+			err := tt.yaml.Provide(&cfg)
+			if tt.expectErr {
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Fatalf("Not found error expected, but was: %v\n", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+			if cfg.StringField != tt.expectedVal {
+				t.Errorf("Value is '%s', but %q expected", cfg.StringField, tt.expectedVal)
+			}
+			if tt.yaml.File() != tt.expectedFile {
+				t.Errorf("Value is '%s', but %q expected", tt.yaml.File(), tt.expectedFile)
+			}
+		})
+	}
+}
+
+func TestYamlRelativeOverride(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	if err := os.WriteFile(filepath.Join(dir, "local.yaml"), []byte("stringfield: local"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("YAML_TEST_CONFIG", "local.yaml")
+
+	y := &Yaml{EnvVar: "YAML_TEST_CONFIG"}
+	cfg := testCfg{}
+	if err := y.Provide(&cfg); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	if cfg.StringField != "local" {
+		t.Errorf("Value is '%s', but %q expected", cfg.StringField, "local")
+	}
+	if expected := filepath.Join(dir, "local.yaml"); y.File() != expected {
+		t.Errorf("Value is '%s', but %q expected", y.File(), expected)
+	}
+}
+
+func TestYamlFileConcurrent(t *testing.T) {
+	y := &Yaml{FS: fstest.MapFS{"config.yaml": {Data: []byte("stringfield: value")}}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			if err := y.Provide(&testCfg{}); err != nil {
+				t.Errorf("No error expected, but was: %v\n", err)
+			}
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		_ = y.File()
+	}
+	<-done
+	if y.File() != "config.yaml" {
+		t.Errorf("Value is '%s', but %q expected", y.File(), "config.yaml")
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		found    bool
+	}{
+		{[]string{"-v", "--config", "a.yaml"}, "a.yaml", true},
+		{[]string{"-config=b.yaml"}, "b.yaml", true},
+		{[]string{"--config=c.yaml", "--config", "d.yaml"}, "c.yaml", true},
+		{[]string{"--configs=e.yaml", "config"}, "", false},
+		{[]string{"--", "--config", "f.yaml"}, "", false},
+		{[]string{"--config"}, "", false},
+	}
+
+	for _, tt := range tests {
+		v, ok := flagValue(tt.args, "config")
+		if v != tt.expected || ok != tt.found {
+			t.Errorf("Value is '%s, %v', but '%s, %v' expected", v, ok, tt.expected, tt.found)
+		}
+	}
+}
+
+func TestYamlMultiDocument(t *testing.T) {
+	content := `stringfield: base
+intfield: 1
+labels:
+  team: core
+  debug: "true"
+---
+profile: prod
+intfield: 2
+labels:
+  debug: ~
+---
+when: [staging, test]
+intfield: 3
+---
+labels:
+  stage: all
+`
+	type cfg struct {
+		StringField string
+		IntField    int
+		Labels      map[string]string
+	}
+
+	tests := []struct {
+		name           string
+		profiles       []string
+		expectedInt    int
+		expectedLabels map[string]string
+	}{
+		{
+			name:           "No profile",
+			expectedInt:    1,
+			expectedLabels: map[string]string{"team": "core", "debug": "true", "stage": "all"},
+		},
+		{
+			name:           "Prod profile",
+			profiles:       []string{"prod"},
+			expectedInt:    2,
+			expectedLabels: map[string]string{"team": "core", "stage": "all"},
+		},
+		{
+			name:           "Test profile",
+			profiles:       []string{"test"},
+			expectedInt:    3,
+			expectedLabels: map[string]string{"team": "core", "debug": "true", "stage": "all"},
+		},
+		{
+			name:           "Multiple profiles",
+			profiles:       []string{"prod", "staging"},
+			expectedInt:    3,
+			expectedLabels: map[string]string{"team": "core", "stage": "all"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := cfg{}
+			y := Yaml{FS: fstest.MapFS{"config.yaml": {Data: []byte(content)}}, Profiles: tt.profiles}
+			if err := y.Provide(&conf); err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+			if conf.StringField != "base" {
+				t.Errorf("Value is '%s', but %q expected", conf.StringField, "base")
+			}
+			if conf.IntField != tt.expectedInt {
+				t.Errorf("Value is '%d', but %d expected", conf.IntField, tt.expectedInt)
+			}
+			if !reflect.DeepEqual(conf.Labels, tt.expectedLabels) {
+				t.Errorf("Value is '%v', but %v expected", conf.Labels, tt.expectedLabels)
+			}
+		})
+	}
+}
+
+func TestYamlMultiDocumentUnset(t *testing.T) {
+	content := `labels:
+  debug: ~
+---
+labels:
+  stage: prod
+`
+	type cfg struct {
+		Labels map[string]string
+	}
+
+	c := New()
+	c.WithProviders(&Map{Values: map[string]interface{}{"labels.debug": "true", "labels.team": "core"}},
+		&Yaml{FS: fstest.MapFS{"config.yaml": {Data: []byte(content)}}})
+	conf := cfg{}
+	if err := c.Parse(&conf); err != nil {
+		t.Fatalf("No error expected, but was: %v\n", err)
+	}
+	expected := map[string]string{"team": "core", "stage": "prod"}
+	if !reflect.DeepEqual(conf.Labels, expected) {
+		t.Errorf("Value is '%v', but %v expected", conf.Labels, expected)
+	}
+}
+
+func TestYamlSingleDocumentProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []string
+		expected string
+	}{
+		{"Inactive", []string{"dev"}, ""},
+		{"Active", []string{"prod"}, "prod value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "profile: prod\nstringfield: prod value\n"
+			y := Yaml{FS: fstest.MapFS{"config.yaml": {Data: []byte(content)}}, Profiles: tt.profiles, Strict: true}
+			conf := testCfg{}
+			if err := y.Provide(&conf); err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+			if conf.StringField != tt.expected {
+				t.Errorf("Value is '%s', but %q expected", conf.StringField, tt.expected)
+			}
+		})
+	}
+}
+
+func TestYamlProfileField(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Single document", "profile: prod"},
+		{"Multiple documents", "name: app\n---\nprofile: prod"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := struct {
+				Name    string
+				Profile string
+			}{}
+			y := Yaml{FS: fstest.MapFS{"config.yaml": {Data: []byte(tt.content)}}, Profiles: []string{"dev"}, Strict: true}
+			if err := y.Provide(&conf); err != nil {
+				t.Fatalf("No error expected, but was: %v\n", err)
+			}
+			if conf.Profile != "prod" {
+				t.Errorf("Value is '%s', but %q expected", conf.Profile, "prod")
+			}
+		})
+	}
+}
+
+func TestYamlStrict(t *testing.T) {
+	type server struct {
+		Host string
+	}
+	type cfg struct {
+		DumpOutput
+		Logging  `yaml:",inline"`
+		Name     string
+		Servers  []server          `yaml:"hosts"`
+		Backends map[string]server `yaml:"backends"`
+		Any      interface{}
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"valid", "format: json\nlevel: debug\nname: app\nhosts:\n  - host: a\nbackends:\n  eu:\n    host: b\nany:\n  x: 1\n", ""},
+		{"profiles", "name: app\n---\nprofile: prod\nlabels:\n  team: core\n", ""},
+		{"top-level", "name: app\nport: 80\n", `unknown field "port"`},
+		{"go field name", "servers:\n  - host: a\n", `unknown field "servers"`},
+		{"slice", "hosts:\n  - host: a\n  - hots: b\n", `unknown field "hosts.1.hots"`},
+		{"map", "backends:\n  eu:\n    hots: b\n", `unknown field "backends.eu.hots"`},
+		{"inactive document", "name: app\n---\nprofile: dev\nport: 80\n", ""},
+		{"single document profile", "profile: prod\nname: app\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			y := Yaml{FS: fstest.MapFS{"config.yaml": {Data: []byte(tt.content)}}, Profiles: []string{"prod"}, Strict: true}
+			err := y.Provide(&cfg{})
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("No error expected, but was: %v\n", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Error expected, but there is none.")
+			}
+			if !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Value is '%v', but %q expected", err, tt.err)
+			}
+		})
+	}
+}